@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"strings"
 
@@ -27,6 +28,9 @@ type Config struct {
 	AccessToken string
 	Region      string
 	EndpointURL string
+
+	// Client sharing the connection settings of the environment; the default one is used if nil
+	HTTPClient *http.Client
 }
 
 func NewClient(ctx context.Context, cfgCreds Config) (*Client, error) {
@@ -37,10 +41,17 @@ func NewClient(ctx context.Context, cfgCreds Config) (*Client, error) {
 	))
 
 	// Load AWS configuration with credentials and region
-	cfg, err := config.LoadDefaultConfig(ctx,
+	opts := []func(*config.LoadOptions) error{
 		config.WithCredentialsProvider(creds),
 		config.WithRegion(cfgCreds.Region),
-	)
+	}
+	if cfgCreds.HTTPClient != nil {
+		// The client retries transient failures itself: retrying in the SDK too would multiply attempts
+		opts = append(opts, config.WithHTTPClient(cfgCreds.HTTPClient), config.WithRetryer(func() aws.Retryer {
+			return aws.NopRetryer{}
+		}))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package commands
+
+import (
+	"strings"
+
+	"dhcli/core"
+	"dhcli/core/flags"
+	"dhcli/core/service"
+
+	"github.com/spf13/cobra"
+)
+
+var apiFlag = flags.SpecificCommandFlag{}
+
+var apiCmd = &cobra.Command{
+	Use:   "api <method> <path>",
+	Short: "Send a request to an endpoint of the core API",
+	Long: `Send an authenticated request to a path of the core API, resolved against /api/<version>
+(and /-/<project> with --project), and print the response as JSON or YAML. Paths starting with /api/
+are sent as they are, and cannot be combined with --project. For example:
+
+  dhcli api GET functions -p my-project --field kind=python --paginate
+  dhcli api POST artifacts -p my-project -f artifact.yaml --field metadata.description=test`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Absolute paths are not resolved under a project, which would otherwise be ignored
+		if cmd.Flags().Changed("project") && strings.HasPrefix("/"+strings.TrimPrefix(args[1], "/"), "/api/") {
+			core.Fail("Api failed", core.UsageErrorf("--project cannot be used with a path starting with /api/"))
+		}
+
+		err := service.ApiHandler(
+			flags.CommonFlag.EnvFlag,
+			flags.CommonFlag.OutFlag,
+			flags.CommonFlag.ProjectFlag,
+			args[0],
+			args[1],
+			apiFlag.FilePathFlag,
+			apiFlag.FieldFlag,
+			apiFlag.PaginateFlag)
+
+		if err != nil {
+			core.Fail("Api failed", err)
+		}
+	},
+}
+
+func init() {
+	flags.AddCommonFlags(apiCmd, "env", "out", "project")
+
+	apiCmd.Flags().StringVarP(&apiFlag.FilePathFlag, "file", "f", "", "path to a JSON or YAML file containing the request body")
+	apiCmd.Flags().StringArrayVar(&apiFlag.FieldFlag, "field", nil, "key=value field of the body, or query parameter with GET and DELETE (may be repeated)")
+	apiCmd.Flags().BoolVar(&apiFlag.PaginateFlag, "paginate", false, "fetch all pages of a paginated collection and print their content")
+	core.RegisterCommand(apiCmd)
+}
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package commands
+
+import (
+	"dhcli/core"
+	"dhcli/core/flags"
+	"dhcli/core/service"
+
+	"github.com/spf13/cobra"
+)
+
+var configFlag = flags.SpecificCommandFlag{}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the CLI configuration",
+}
+
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Show the effective configuration of an environment",
+	Long: `Show the effective configuration of an environment, combining built-in defaults,
+the ini file, the context file, DHCORE_* and AWS_* environment variables and command-line flags.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := service.ConfigViewHandler(
+			flags.CommonFlag.EnvFlag,
+			flags.FlagSources["env"],
+			flags.CommonFlag.OutFlag,
+			flags.CommonFlag.ProjectFlag,
+			flags.FlagSources["project"],
+			configFlag.ResolvedFlag); err != nil {
+			core.Fail("Config view failed", err)
+		}
+	},
+}
+
+func init() {
+	flags.AddCommonFlags(configViewCmd, "env", "out", "project")
+
+	configViewCmd.Flags().BoolVar(&configFlag.ResolvedFlag, "resolved", false, "show where each value comes from")
+
+	configCmd.AddCommand(configViewCmd)
+	core.RegisterCommand(configCmd)
+}
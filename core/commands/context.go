@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package commands
+
+import (
+	"dhcli/core"
+	"dhcli/core/flags"
+	"dhcli/core/service"
+
+	"github.com/spf13/cobra"
+)
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Manage the per-directory context file",
+	Long: `Manage the .dhcli.yaml context file, looked up in the current directory and its parents.
+Its environment, project, output and labels are used for flags not set on the command line; the
+environment also applies to commands taking it as an argument, such as login, when it is omitted.`,
+}
+
+var contextShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the context in use",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Show the file content, not the defaults it would apply
+		output := flags.CommonFlag.OutFlag
+		if !cmd.Flags().Changed("out") {
+			output = "short"
+		}
+		if err := service.ContextShowHandler(output); err != nil {
+			core.Fail("Context show failed", err)
+		}
+	},
+}
+
+var contextSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a context value (environment, project, output or labels)",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := service.ContextSetHandler(args[0], args[1]); err != nil {
+			core.Fail("Context set failed", err)
+		}
+	},
+}
+
+var contextUnsetCmd = &cobra.Command{
+	Use:   "unset [key...]",
+	Short: "Remove context values, or the whole context if no key is given",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := service.ContextUnsetHandler(args); err != nil {
+			core.Fail("Context unset failed", err)
+		}
+	},
+}
+
+func init() {
+	flags.AddCommonFlags(contextShowCmd, "out")
+
+	contextCmd.AddCommand(contextShowCmd)
+	contextCmd.AddCommand(contextSetCmd)
+	contextCmd.AddCommand(contextUnsetCmd)
+	core.RegisterCommand(contextCmd)
+}
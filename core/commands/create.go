@@ -8,7 +8,6 @@ import (
 	"dhcli/core"
 	"dhcli/core/flags"
 	"dhcli/core/service"
-	"log"
 
 	"github.com/spf13/cobra"
 )
@@ -26,15 +25,16 @@ var createCmd = &cobra.Command{
 			flags.CommonFlag.NameFlag,
 			createFlag.FilePathFlag,
 			createFlag.ResetIdFlag,
+			flags.CommonFlag.LabelFlag,
 			args[0])
 		if err != nil {
-			log.Fatalf("Create failed: %v", err)
+			core.Fail("Create failed", err)
 		}
 	},
 }
 
 func init() {
-	flags.AddCommonFlags(createCmd, "env", "project", "name")
+	flags.AddCommonFlags(createCmd, "env", "project", "name", "label")
 
 	// Add file flags
 	createCmd.Flags().BoolVarP(&createFlag.ResetIdFlag, "reset-id", "r", false, "if set, removes the id field from the file to ensure the server assigns a new one")
@@ -9,7 +9,6 @@ import (
 	"dhcli/core/flags"
 	"dhcli/core/service"
 	"errors"
-	"log"
 
 	"github.com/spf13/cobra"
 )
@@ -41,7 +40,7 @@ var deleteCmd = &cobra.Command{
 			id)
 
 		if err != nil {
-			log.Fatalf("Delete failed: %v", err)
+			core.Fail("Delete failed", err)
 		}
 	},
 }
@@ -10,7 +10,6 @@ import (
 	"dhcli/core/service"
 	"errors"
 	"github.com/spf13/cobra"
-	"log"
 )
 
 var downloadFlag = flags.SpecificCommandFlag{}
@@ -39,7 +38,7 @@ var downloadCmd = &cobra.Command{
 			args[0],
 			id,
 			args[1:]); err != nil {
-			log.Fatalf("Download failed: %v", err)
+			core.Fail("Download failed", err)
 		}
 	},
 }
@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package commands
+
+import (
+	"dhcli/core"
+	"dhcli/core/flags"
+	"dhcli/core/service"
+
+	"github.com/spf13/cobra"
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Manage environments",
+}
+
+var envListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available environments",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := service.ListEnvHandler(flags.CommonFlag.OutFlag); err != nil {
+			core.Fail("Env list failed", err)
+		}
+	},
+}
+
+var envShowCmd = &cobra.Command{
+	Use:   "show [environment]",
+	Short: "Show the configuration of an environment, with secrets masked",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		env := flags.EnvironmentArg(args)
+
+		if err := service.ShowEnvHandler(env, flags.CommonFlag.OutFlag); err != nil {
+			core.Fail("Env show failed", err)
+		}
+	},
+}
+
+var envExportFlag = flags.SpecificCommandFlag{}
+
+var envExportCmd = &cobra.Command{
+	Use:   "export [environment]",
+	Short: "Export an environment as a bundle that can be shared and imported",
+	Long:  "Export an environment as a YAML or JSON bundle. Tokens and secrets are left out, unless --include-secrets is set.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		env := flags.EnvironmentArg(args)
+
+		if err := service.ExportEnvHandler(env, flags.CommonFlag.OutFlag, envExportFlag.FilePathFlag, envExportFlag.IncludeSecretsFlag); err != nil {
+			core.Fail("Env export failed", err)
+		}
+	},
+}
+
+var envImportFlag = flags.SpecificCommandFlag{}
+
+var envImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import an environment from a bundle",
+	Long:  "Import an environment from a bundle created by env export. The bundle is checked against the core it points to, when reachable.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := service.ImportEnvHandler(args[0], flags.CommonFlag.NameFlag, envImportFlag.OverwriteFlag); err != nil {
+			core.Fail("Env import failed", err)
+		}
+	},
+}
+
+var envRenameCmd = &cobra.Command{
+	Use:   "rename <environment> <new-name>",
+	Short: "Rename an environment",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := service.RenameEnvHandler(args[0], args[1]); err != nil {
+			core.Fail("Env rename failed", err)
+		}
+	},
+}
+
+var envCopyCmd = &cobra.Command{
+	Use:   "copy <environment> <new-name>",
+	Short: "Copy an environment under a new name",
+	Long:  "Copy an environment under a new name, for example to log in to the same core with a second account. Tokens are not copied.",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := service.CopyEnvHandler(args[0], args[1]); err != nil {
+			core.Fail("Env copy failed", err)
+		}
+	},
+}
+
+var envSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a key of an environment",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := service.SetEnvKeyHandler(flags.CommonFlag.EnvFlag, args[0], args[1]); err != nil {
+			core.Fail("Env set failed", err)
+		}
+	},
+}
+
+var envUnsetCmd = &cobra.Command{
+	Use:   "unset <key>...",
+	Short: "Remove keys from an environment",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := service.UnsetEnvKeysHandler(flags.CommonFlag.EnvFlag, args); err != nil {
+			core.Fail("Env unset failed", err)
+		}
+	},
+}
+
+var envUpdateCmd = &cobra.Command{
+	Use:   "update [environment]",
+	Short: "Update an environment from the core configuration",
+	Long:  "Fetch the configuration of the core and show which keys of the environment changed. Environments are also updated automatically once older than their update_interval (default 1h, 0 disables it).",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		env := flags.EnvironmentArg(args)
+
+		if err := service.UpdateEnvHandler(env); err != nil {
+			core.Fail("Env update failed", err)
+		}
+	},
+}
+
+func init() {
+	flags.AddCommonFlags(envListCmd, "out")
+	flags.AddCommonFlags(envShowCmd, "out")
+	flags.AddCommonFlags(envExportCmd, "out")
+	flags.AddCommonFlags(envImportCmd, "name")
+	flags.AddCommonFlags(envSetCmd, "env")
+	flags.AddCommonFlags(envUnsetCmd, "env")
+
+	envExportCmd.Flags().StringVarP(&envExportFlag.FilePathFlag, "file", "f", "", "path of the file to write the bundle to (default standard output)")
+	envExportCmd.Flags().BoolVar(&envExportFlag.IncludeSecretsFlag, "include-secrets", false, "include tokens and secrets in the bundle")
+	envImportCmd.Flags().BoolVar(&envImportFlag.OverwriteFlag, "overwrite", false, "replace an existing environment with the same name")
+
+	envCmd.AddCommand(envListCmd)
+	envCmd.AddCommand(envShowCmd)
+	envCmd.AddCommand(envExportCmd)
+	envCmd.AddCommand(envImportCmd)
+	envCmd.AddCommand(envRenameCmd)
+	envCmd.AddCommand(envCopyCmd)
+	envCmd.AddCommand(envSetCmd)
+	envCmd.AddCommand(envUnsetCmd)
+	envCmd.AddCommand(envUpdateCmd)
+	core.RegisterCommand(envCmd)
+}
@@ -9,11 +9,12 @@ import (
 	"dhcli/core/flags"
 	"dhcli/core/service"
 	"errors"
-	"log"
 
 	"github.com/spf13/cobra"
 )
 
+var getFlag = flags.SpecificCommandFlag{}
+
 var getCmd = &cobra.Command{
 	Use:   "get <resource> [id]",
 	Short: "Retrieve a resource",
@@ -35,15 +36,18 @@ var getCmd = &cobra.Command{
 			flags.CommonFlag.ProjectFlag,
 			flags.CommonFlag.NameFlag,
 			args[0],
-			id)
+			id,
+			getFlag.NoHeadersFlag)
 
 		if err != nil {
-			log.Fatalf("Get failed: %v", err)
+			core.Fail("Get failed", err)
 		}
 	},
 }
 
 func init() {
 	flags.AddCommonFlags(getCmd)
+
+	getCmd.Flags().BoolVar(&getFlag.NoHeadersFlag, "no-headers", false, "print table rows only, without headers and borders, with wide and custom-columns output")
 	core.RegisterCommand(getCmd)
 }
@@ -8,7 +8,6 @@ import (
 	"dhcli/core"
 	"dhcli/core/flags"
 	"dhcli/core/service"
-	"log"
 
 	"github.com/spf13/cobra"
 )
@@ -25,7 +24,7 @@ var initCmd = &cobra.Command{
 			env = args[0]
 		}
 		if err := service.InitEnvironmentHandler(env, initFlag.PreFlag); err != nil {
-			log.Fatalf("Init failed: %v", err)
+			core.Fail("Init failed", err)
 		}
 	},
 }
@@ -8,7 +8,6 @@ import (
 	"dhcli/core"
 	"dhcli/core/flags"
 	"dhcli/core/service"
-	"log"
 
 	"github.com/spf13/cobra"
 )
@@ -28,8 +27,9 @@ var listCmd = &cobra.Command{
 			listFlag.ListKind,
 			listFlag.ListState,
 			args[0],
+			listFlag.NoHeadersFlag,
 		); err != nil {
-			log.Fatalf("List failed: %v", err)
+			core.Fail("List failed", err)
 		}
 	},
 }
@@ -40,6 +40,7 @@ func init() {
 	// Add specific command flags
 	listCmd.Flags().StringVarP(&listFlag.ListKind, "kind", "k", "", "kind")
 	listCmd.Flags().StringVarP(&listFlag.ListState, "state", "s", "", "state")
+	listCmd.Flags().BoolVar(&listFlag.NoHeadersFlag, "no-headers", false, "print table rows only, without headers and borders")
 
 	core.RegisterCommand(listCmd)
 }
@@ -12,11 +12,13 @@ import (
 )
 
 var listEnvCmd = &cobra.Command{
-	Use:   "list-env",
-	Short: "List available environments",
+	Use:        "list-env",
+	Short:      "List available environments",
+	Deprecated: "use 'env list' instead.",
 	Run: func(cmd *cobra.Command, args []string) {
-
-		service.ListEnvHandler()
+		if err := service.ListEnvHandler("short"); err != nil {
+			core.Fail("List failed", err)
+		}
 	},
 }
 
@@ -6,29 +6,45 @@ package commands
 
 import (
 	"dhcli/core"
+	"dhcli/core/flags"
 	"dhcli/core/service"
-	"log"
 
 	"github.com/spf13/cobra"
 )
 
+var loginFlag = flags.SpecificCommandFlag{}
+
 var loginCmd = &cobra.Command{
 	Use:   "login [environment]",
 	Short: "Log in to a given environment",
-	Long:  "Authenticate the user using OAuth2 PKCE flow with the specified environment.",
-	Args:  cobra.MaximumNArgs(1),
+	Long: `Authenticate the user using OAuth2 PKCE flow with the specified environment.
+Use --device on hosts without a browser, --client-credentials or --token for non-interactive logins.`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		var environment string
-		if len(args) > 0 {
-			environment = args[0]
-		}
+		environment := flags.EnvironmentArg(args)
 
-		if err := service.LoginHandler(environment); err != nil {
-			log.Fatalf("Login failed: %v", err)
+		if err := service.LoginHandler(
+			environment,
+			loginFlag.DeviceFlag,
+			loginFlag.ClientCredentialsFlag,
+			loginFlag.ClientIdFlag,
+			loginFlag.ClientSecretFlag,
+			loginFlag.TokenFlag,
+			loginFlag.PortFlag,
+			loginFlag.TimeoutFlag); err != nil {
+			core.Fail("Login failed", err)
 		}
 	},
 }
 
 func init() {
+	loginCmd.Flags().BoolVar(&loginFlag.DeviceFlag, "device", false, "use the device authorization grant, for hosts without a browser")
+	loginCmd.Flags().BoolVar(&loginFlag.ClientCredentialsFlag, "client-credentials", false, "use the client credentials grant (client id and secret can also be set with DHCORE_CLIENT_ID and DHCORE_CLIENT_SECRET)")
+	loginCmd.Flags().StringVar(&loginFlag.ClientIdFlag, "client-id", "", "client id for the client credentials grant")
+	loginCmd.Flags().StringVar(&loginFlag.ClientSecretFlag, "client-secret", "", "client secret for the client credentials grant")
+	loginCmd.Flags().BoolVar(&loginFlag.TokenFlag, "token", false, "store a personal access token read from standard input")
+	loginCmd.Flags().IntVar(&loginFlag.PortFlag, "port", service.DefaultCallbackPort, "port of the local callback server, 0 to pick a free one (the redirect URI must be allowed by the client)")
+	loginCmd.Flags().DurationVar(&loginFlag.TimeoutFlag, "timeout", service.DefaultLoginTimeout, "maximum time to wait for the browser or device login to complete")
+	loginCmd.MarkFlagsMutuallyExclusive("device", "client-credentials", "token")
 	core.RegisterCommand(loginCmd)
 }
@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package commands
+
+import (
+	"dhcli/core"
+	"dhcli/core/flags"
+	"dhcli/core/service"
+
+	"github.com/spf13/cobra"
+)
+
+var logoutFlag = flags.SpecificCommandFlag{}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout [environment]",
+	Short: "Log out of a given environment",
+	Long:  "Revoke the tokens of the specified environment, if the provider supports it, and remove them from the configuration.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		environment := flags.EnvironmentArg(args)
+
+		if err := service.LogoutHandler(environment, logoutFlag.AllFlag); err != nil {
+			core.Fail("Logout failed", err)
+		}
+	},
+}
+
+func init() {
+	logoutCmd.Flags().BoolVarP(&logoutFlag.AllFlag, "all", "a", false, "log out of every registered environment")
+	core.RegisterCommand(logoutCmd)
+}
@@ -8,7 +8,6 @@ import (
 	"dhcli/core"
 	"dhcli/core/flags"
 	"dhcli/core/service"
-	"log"
 
 	"github.com/spf13/cobra"
 )
@@ -25,7 +24,7 @@ var operateRunCmd = &cobra.Command{
 			args[2])
 
 		if err != nil {
-			log.Fatalf("Failed: %v", err)
+			core.Fail("Failed", err)
 		}
 	},
 }
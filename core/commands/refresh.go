@@ -6,6 +6,7 @@ package commands
 
 import (
 	"dhcli/core"
+	"dhcli/core/flags"
 	"dhcli/core/service"
 
 	"github.com/spf13/cobra"
@@ -17,12 +18,11 @@ var refreshCmd = &cobra.Command{
 	Long:  "Refresh the access token of a given environment.",
 	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		var environment string
-		if len(args) > 0 {
-			environment = args[0]
-		}
+		environment := flags.EnvironmentArg(args)
 
-		service.RefreshHandler(environment)
+		if err := service.RefreshHandler(environment); err != nil {
+			core.Fail("Refresh failed", err)
+		}
 	},
 }
 
@@ -8,7 +8,6 @@ import (
 	"dhcli/core"
 	"dhcli/core/flags"
 	"dhcli/core/service"
-	"log"
 
 	"github.com/spf13/cobra"
 )
@@ -22,13 +21,36 @@ var registerCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		endpoint := args[0]
 
-		if err := service.RegisterHandler(registerFlag.EnvFlag, endpoint); err != nil {
-			log.Fatalf("Registration failed: %v", err)
+		if !registerFlag.ManualFlag {
+			for _, f := range []string{"api-version", "api-level", "issuer", "client-id"} {
+				if cmd.Flags().Changed(f) {
+					core.Fail("Registration failed", core.UsageErrorf("--%v requires --manual", f))
+				}
+			}
+		}
+
+		if err := service.RegisterHandler(
+			registerFlag.EnvFlag,
+			endpoint,
+			registerFlag.ManualFlag,
+			registerFlag.NoAuthFlag,
+			registerFlag.ApiVersionFlag,
+			registerFlag.ApiLevelFlag,
+			registerFlag.IssuerFlag,
+			registerFlag.ClientIdFlag,
+		); err != nil {
+			core.Fail("Registration failed", err)
 		}
 	},
 }
 
 func init() {
 	registerCmd.Flags().StringVarP(&registerFlag.EnvFlag, "env", "e", "", "environment")
+	registerCmd.Flags().BoolVar(&registerFlag.ManualFlag, "manual", false, "register without fetching the core configuration, taking values from flags or prompts")
+	registerCmd.Flags().BoolVar(&registerFlag.NoAuthFlag, "no-auth", false, "register a core that does not require authentication; requests are sent without a token")
+	registerCmd.Flags().StringVar(&registerFlag.ApiVersionFlag, "api-version", "", "API version of the core (with --manual, default v1)")
+	registerCmd.Flags().StringVar(&registerFlag.ApiLevelFlag, "api-level", "", "API level of the core (with --manual)")
+	registerCmd.Flags().StringVar(&registerFlag.IssuerFlag, "issuer", "", "OpenID issuer of the core (with --manual)")
+	registerCmd.Flags().StringVar(&registerFlag.ClientIdFlag, "client-id", "", "client id of the CLI (with --manual)")
 	core.RegisterCommand(registerCmd)
 }
@@ -16,7 +16,9 @@ var removeCmd = &cobra.Command{
 	Short: "Remove an environment from the configuration",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		service.RemoveHandler(args[0])
+		if err := service.RemoveHandler(args[0]); err != nil {
+			core.Fail("Remove failed", err)
+		}
 	},
 }
 
@@ -8,7 +8,6 @@ import (
 	"dhcli/core"
 	"dhcli/core/flags"
 	"dhcli/core/service"
-	"log"
 
 	"github.com/spf13/cobra"
 )
@@ -24,7 +23,7 @@ var runLogsCmd = &cobra.Command{
 			args[1])
 
 		if err != nil {
-			log.Fatalf("Failed: %v", err)
+			core.Fail("Failed", err)
 		}
 	},
 }
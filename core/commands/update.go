@@ -8,7 +8,6 @@ import (
 	"dhcli/core"
 	"dhcli/core/flags"
 	"dhcli/core/service"
-	"log"
 
 	"github.com/spf13/cobra"
 )
@@ -28,7 +27,7 @@ var updateCmd = &cobra.Command{
 			args[1])
 
 		if err != nil {
-			log.Fatalf("Update failed: %v", err)
+			core.Fail("Update failed", err)
 		}
 	},
 }
@@ -27,7 +27,7 @@ var uploadCmd = &cobra.Command{
 		//	args[0],
 		//	id,
 		//	args[1:]); err != nil {
-		//	log.Fatalf("Download failed: %v", err)
+		//	core.Fail("Download failed", err)
 		//}
 	},
 }
@@ -16,7 +16,9 @@ var useCmd = &cobra.Command{
 	Short: "Sets the default environment",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		service.UseHandler(args[0])
+		if err := service.UseHandler(args[0]); err != nil {
+			core.Fail("Use failed", err)
+		}
 	},
 }
 
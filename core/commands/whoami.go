@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package commands
+
+import (
+	"dhcli/core"
+	"dhcli/core/flags"
+	"dhcli/core/service"
+
+	"github.com/spf13/cobra"
+)
+
+var whoamiFlag = flags.SpecificCommandFlag{}
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Show the identity and token details of the current session",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := service.WhoamiHandler(
+			flags.CommonFlag.EnvFlag,
+			flags.CommonFlag.OutFlag,
+			whoamiFlag.UserinfoFlag); err != nil {
+			core.Fail("Whoami failed", err)
+		}
+	},
+}
+
+func init() {
+	flags.AddCommonFlags(whoamiCmd, "env", "out")
+
+	whoamiCmd.Flags().BoolVarP(&whoamiFlag.UserinfoFlag, "userinfo", "u", false, "also query the OpenID userinfo endpoint")
+
+	core.RegisterCommand(whoamiCmd)
+}
@@ -5,6 +5,8 @@
 package core
 
 import (
+	"dhcli/core/flags"
+	"dhcli/utils"
 	"fmt"
 	"github.com/spf13/cobra"
 	"os"
@@ -14,15 +16,72 @@ var dhcli = &cobra.Command{
 	Use:   "dhcli",
 	Short: "dhcli is a tool for managing resource in core platform",
 	Long:  `dhcli is a command-line utility for downloading, uploading, and managing core platform entity`,
+	// Errors are reported by Execute, in the format set with --error-format
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if f := flags.CommonFlag.ErrorFormatFlag; f != ErrorFormatText && f != ErrorFormatJSON {
+			flags.CommonFlag.ErrorFormatFlag = ErrorFormatText
+			Fail("", UsageErrorf("invalid --error-format '%v', use %v or %v", f, ErrorFormatText, ErrorFormatJSON))
+		}
+
+		flags.ApplyDefaults(cmd)
+		if cmd.Flags().Changed("retries") {
+			if flags.CommonFlag.RetriesFlag < 0 {
+				Fail("", UsageErrorf("invalid --retries %v: must not be negative", flags.CommonFlag.RetriesFlag))
+			}
+			utils.SetRetries(flags.CommonFlag.RetriesFlag)
+		}
+
+		switch {
+		case flags.CommonFlag.RecordFlag != "" && flags.CommonFlag.ReplayFlag != "":
+			Fail("", UsageErrorf("--record and --replay cannot be used together"))
+		case flags.CommonFlag.RecordFlag != "":
+			if err := utils.SetCassette(utils.CassetteRecord, flags.CommonFlag.RecordFlag); err != nil {
+				Fail("", err)
+			}
+		case flags.CommonFlag.ReplayFlag != "":
+			if err := utils.SetCassette(utils.CassetteReplay, flags.CommonFlag.ReplayFlag); err != nil {
+				Fail("", err)
+			}
+		}
+
+		switch {
+		case flags.CommonFlag.DebugFlag:
+			utils.SetTrace(utils.TraceDebug, flags.CommonFlag.PrintCurlFlag)
+		case flags.CommonFlag.VerboseFlag:
+			utils.SetTrace(utils.TraceVerbose, flags.CommonFlag.PrintCurlFlag)
+		case flags.CommonFlag.PrintCurlFlag:
+			utils.SetTrace(utils.TraceOff, true)
+		}
+	},
+}
+
+func init() {
+	dhcli.PersistentFlags().IntVar(&flags.CommonFlag.RetriesFlag, "retries", 0,
+		"retries of requests failing for transient reasons, overriding the environment setting (default 3)")
+	dhcli.PersistentFlags().BoolVarP(&flags.CommonFlag.VerboseFlag, "verbose", "v", false,
+		"log method, URL, status and latency of each HTTP request")
+	dhcli.PersistentFlags().BoolVar(&flags.CommonFlag.DebugFlag, "debug", false,
+		"log headers and bodies of each HTTP request too, with credentials redacted")
+	dhcli.PersistentFlags().BoolVar(&flags.CommonFlag.PrintCurlFlag, "print-curl", false,
+		"print an equivalent curl command for each HTTP request, with credentials redacted")
+	dhcli.PersistentFlags().StringVar(&flags.CommonFlag.RecordFlag, "record", "",
+		"save each HTTP exchange to a file in this directory, with credentials redacted")
+	dhcli.PersistentFlags().StringVar(&flags.CommonFlag.ReplayFlag, "replay", "",
+		"serve HTTP responses from the exchanges saved with --record in this directory, instead of the network")
+	dhcli.PersistentFlags().StringVar(&flags.CommonFlag.ErrorFormatFlag, "error-format", ErrorFormatText,
+		"format of error messages (text, json); see the README for exit codes")
 }
 
 func Execute() {
-	if err := dhcli.Execute(); err != nil {
-		_, err := fmt.Fprintln(os.Stderr, err)
-		if err != nil {
-			return
+	// Commands report their own failures with Fail: errors here are about arguments and flags
+	if cmd, err := dhcli.ExecuteC(); err != nil {
+		if flags.CommonFlag.ErrorFormatFlag != ErrorFormatJSON {
+			fmt.Fprintf(os.Stderr, "Error: %v\nRun '%v --help' for usage.\n", err, cmd.CommandPath())
+			os.Exit(ExitUsage)
 		}
-		os.Exit(1)
+		Fail("", UsageErrorf("%w", err))
 	}
 }
 
@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+
+	"dhcli/core/flags"
+	"dhcli/pkg/dhcore"
+	"dhcli/utils"
+)
+
+// Exit codes, documented in the README: scripts rely on them, so existing values must not change
+const (
+	ExitOK         = 0
+	ExitError      = 1 // any error not covered below
+	ExitUsage      = 2 // invalid command, arguments or flags
+	ExitAuth       = 3 // not logged in, or credentials rejected by the core or the token endpoint
+	ExitNotFound   = 4
+	ExitConflict   = 5
+	ExitValidation = 6 // request rejected by the core as invalid
+	ExitAPILevel   = 7 // API level of the core not supported by the command
+	ExitNetwork    = 8 // core or storage unreachable, or request timed out
+	ExitServer     = 9 // core or token endpoint failed with a 5xx status
+)
+
+// Codes reported with --error-format json
+var exitCodeNames = map[int]string{
+	ExitError:      "error",
+	ExitUsage:      "usage",
+	ExitAuth:       "auth",
+	ExitNotFound:   "not_found",
+	ExitConflict:   "conflict",
+	ExitValidation: "validation",
+	ExitAPILevel:   "api_level",
+	ExitNetwork:    "network",
+	ExitServer:     "server",
+}
+
+const (
+	ErrorFormatText = "text"
+	ErrorFormatJSON = "json"
+)
+
+type usageError struct {
+	error
+}
+
+// UsageErrorf returns an error reported with ExitUsage, for invalid arguments or flags
+func UsageErrorf(format string, args ...interface{}) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// ExitCode returns the exit code matching an error
+func ExitCode(err error) int {
+	var usage usageError
+	var tokenErr *utils.TokenError
+	var urlErr *url.Error
+
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &usage):
+		return ExitUsage
+	case errors.Is(err, dhcore.ErrAPILevelUnsupported):
+		return ExitAPILevel
+	case errors.Is(err, dhcore.ErrUnauthorized), errors.Is(err, dhcore.ErrForbidden), errors.Is(err, utils.ErrNotLoggedIn):
+		return ExitAuth
+	case errors.As(err, &tokenErr):
+		if tokenErr.StatusCode >= 500 {
+			return ExitServer
+		}
+		return ExitAuth
+	case errors.Is(err, dhcore.ErrNotFound):
+		return ExitNotFound
+	case errors.Is(err, dhcore.ErrConflict):
+		return ExitConflict
+	case errors.Is(err, dhcore.ErrBadRequest):
+		return ExitValidation
+	case errors.Is(err, dhcore.ErrServer):
+		return ExitServer
+	case errors.As(err, &urlErr) && urlErr.Op != "parse":
+		// Returned by the HTTP client when no response was received
+		return ExitNetwork
+	}
+
+	return ExitError
+}
+
+// Reported on standard error with --error-format json
+type errorReport struct {
+	// HTTP status of the failed request, if any
+	Status  int    `json:"status,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+}
+
+// Fail reports the error of a command in the format set with --error-format, then exits with its exit code
+func Fail(action string, err error) {
+	code := ExitCode(err)
+	message := err.Error()
+	if action != "" {
+		message = action + ": " + message
+	}
+
+	if flags.CommonFlag.ErrorFormatFlag != ErrorFormatJSON {
+		log.Println(message)
+		os.Exit(code)
+	}
+
+	report := errorReport{Code: exitCodeNames[code], Message: message}
+	var apiErr *dhcore.APIError
+	var tokenErr *utils.TokenError
+	if errors.As(err, &apiErr) {
+		report.Status = apiErr.StatusCode
+		report.Path = apiErr.Path
+		if apiErr.Message != "" {
+			report.Message = apiErr.Message
+		}
+	} else if errors.As(err, &tokenErr) {
+		report.Status = tokenErr.StatusCode
+	}
+
+	encoder := json.NewEncoder(os.Stderr)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(report)
+	os.Exit(code)
+}
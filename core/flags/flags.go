@@ -5,7 +5,14 @@
 package flags
 
 import (
+	"log"
+	"os"
+	"strings"
+	"time"
+
 	"github.com/spf13/cobra"
+
+	"dhcli/utils"
 )
 
 type SpecificCommandFlag struct {
@@ -20,6 +27,27 @@ type SpecificCommandFlag struct {
 	ListKind     string
 	ListState    string
 	EnvFlag      string
+	DeviceFlag   bool
+
+	ClientCredentialsFlag bool
+	ClientIdFlag          string
+	ClientSecretFlag      string
+	TokenFlag             bool
+	PortFlag              int
+	TimeoutFlag           time.Duration
+	UserinfoFlag          bool
+	AllFlag               bool
+	ResolvedFlag          bool
+	IncludeSecretsFlag    bool
+	OverwriteFlag         bool
+	ManualFlag            bool
+	NoAuthFlag            bool
+	ApiVersionFlag        string
+	ApiLevelFlag          string
+	IssuerFlag            string
+	FieldFlag             []string
+	PaginateFlag          bool
+	NoHeadersFlag         bool
 }
 
 type commonCommandFlag struct {
@@ -27,26 +55,132 @@ type commonCommandFlag struct {
 	OutFlag     string
 	ProjectFlag string
 	NameFlag    string
+	LabelFlag   []string
+	RetriesFlag int
+
+	VerboseFlag   bool
+	DebugFlag     bool
+	PrintCurlFlag bool
+	RecordFlag    string
+	ReplayFlag    string
+
+	ErrorFormatFlag string
 }
 
 var CommonFlag = commonCommandFlag{}
 
+// Layer each common flag value comes from, see ApplyDefaults
+var FlagSources = map[string]string{}
+
+// Environment variables providing a value for common flags not set on the command line
+var flagEnvVars = map[string]string{
+	"env":     utils.EnvironmentEnvVar,
+	"project": utils.ProjectEnvVar,
+}
+
+var commonFlagsByCmd = map[*cobra.Command][]string{}
+
 func AddCommonFlags(cmd *cobra.Command, flagsToAdd ...string) {
 
 	if len(flagsToAdd) == 0 {
 		flagsToAdd = []string{"env", "out", "project", "name"}
 	}
 
+	commonFlagsByCmd[cmd] = flagsToAdd
+
 	for _, flag := range flagsToAdd {
 		switch flag {
 		case "env":
 			cmd.Flags().StringVarP(&CommonFlag.EnvFlag, "env", "e", "", "environment")
 		case "out":
-			cmd.Flags().StringVarP(&CommonFlag.OutFlag, "out", "o", "short", "output format (short, json, yaml)")
+			cmd.Flags().StringVarP(&CommonFlag.OutFlag, "out", "o", "short", "output format (short, json, yaml; list and get also accept wide, custom-columns=, jsonpath=, go-template= and go-template-file=; list also csv, tsv and ndjson)")
 		case "project":
 			cmd.Flags().StringVarP(&CommonFlag.ProjectFlag, "project", "p", "", "project")
 		case "name":
 			cmd.Flags().StringVarP(&CommonFlag.NameFlag, "name", "n", "", "name")
+		case "label":
+			cmd.Flags().StringSliceVarP(&CommonFlag.LabelFlag, "label", "l", nil, "labels (may be repeated or comma-separated)")
+		}
+	}
+}
+
+// ApplyDefaults fills the common flags of a command that were not set on the command line,
+// from environment variables first and then from the context file
+func ApplyDefaults(cmd *cobra.Command) {
+	var ctx *utils.Context
+
+	for _, name := range commonFlagsByCmd[cmd] {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			continue
+		}
+		if flag.Changed {
+			FlagSources[name] = utils.SourceFlag
+			continue
+		}
+
+		if envVar, ok := flagEnvVars[name]; ok {
+			if value := os.Getenv(envVar); value != "" {
+				if err := flag.Value.Set(value); err == nil {
+					setSource(name, value, utils.SourceEnv)
+				}
+				continue
+			}
+		}
+
+		if ctx == nil {
+			ctx = loadContext()
 		}
+		if value := contextValue(ctx, name); value != "" {
+			if err := flag.Value.Set(value); err == nil {
+				setSource(name, value, utils.SourceContext)
+			}
+		}
+	}
+}
+
+// EnvironmentArg returns the environment of commands taking it as an optional argument, such as login:
+// the argument, else the environment of the context file unless DHCORE_ENV is set. An empty result
+// selects the environment from DHCORE_ENV or the current one, as the env flag does.
+func EnvironmentArg(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	if os.Getenv(utils.EnvironmentEnvVar) != "" {
+		return ""
+	}
+	env := loadContext().Environment
+	utils.SetEnvironmentSource(env, utils.SourceContext)
+	return env
+}
+
+// Records the layer a flag value comes from; the environment is resolved by LoadIniConfig, which reports it too
+func setSource(name string, value string, source string) {
+	FlagSources[name] = source
+	if name == "env" {
+		utils.SetEnvironmentSource(value, source)
+	}
+}
+
+func loadContext() *utils.Context {
+	ctx, path, err := utils.LoadContext()
+	if err != nil {
+		log.Printf("WARNING: Ignoring context file %v: %v\n", path, err)
+		return &utils.Context{}
+	}
+	return ctx
+}
+
+func contextValue(ctx *utils.Context, name string) string {
+	switch name {
+	case "env":
+		return ctx.Environment
+	case "project":
+		return ctx.Project
+	case "out":
+		return ctx.Output
+	case "label":
+		return strings.Join(ctx.Labels, ",")
 	}
+	return ""
 }
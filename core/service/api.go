@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"dhcli/utils"
+)
+
+var apiMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// ApiHandler sends a request to a path of the core API and prints the response. Fields are sent as query
+// parameters with GET and DELETE, otherwise they are set in the JSON body, on top of the file if any.
+func ApiHandler(env string, output string, project string, method string, path string, filePath string, fields []string, paginate bool) error {
+	method = strings.ToUpper(method)
+	if !slices.Contains(apiMethods, method) {
+		return fmt.Errorf("unsupported method '%v', use one of %v", method, strings.Join(apiMethods, ", "))
+	}
+	if paginate && method != http.MethodGet {
+		return errors.New("--paginate is only supported with GET")
+	}
+	if filePath != "" && (method == http.MethodGet || method == http.MethodDelete) {
+		return fmt.Errorf("a request body cannot be sent with %v", method)
+	}
+
+	params := url.Values{}
+	var body []byte
+	if filePath != "" {
+		b, err := readRequestBody(filePath)
+		if err != nil {
+			return err
+		}
+		body = b
+	}
+	if len(fields) > 0 {
+		if method == http.MethodGet || method == http.MethodDelete {
+			for _, field := range fields {
+				key, value, found := strings.Cut(field, "=")
+				if !found || key == "" {
+					return fmt.Errorf("invalid field '%v', use key=value", field)
+				}
+				params.Add(key, value)
+			}
+		} else {
+			b, err := setFields(body, fields)
+			if err != nil {
+				return err
+			}
+			body = b
+		}
+	}
+
+	// The API level is not checked, as the endpoint may be one the CLI knows nothing about
+	client, _, err := utils.LoadCoreClient(env, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	u := client.APIURL(project, path, params)
+
+	var response []byte
+	if paginate {
+		items, err := client.GetAllPages(ctx, u)
+		if err != nil {
+			return fmt.Errorf("error in request: %w", err)
+		}
+		response, err = json.Marshal(items)
+		if err != nil {
+			return err
+		}
+	} else {
+		response, err = client.Do(ctx, method, u, body)
+		if err != nil {
+			return fmt.Errorf("error in request: %w", err)
+		}
+	}
+
+	return printAPIResponse(response, utils.TranslateFormat(output))
+}
+
+// Reads a JSON or YAML file as a JSON request body
+func readRequestBody(filePath string) ([]byte, error) {
+	file, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	body, err := yaml.YAMLToJSON(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert YAML to JSON: %w", err)
+	}
+
+	return body, nil
+}
+
+// Sets key=value fields in a JSON object body; dots in keys denote nested objects, and values that are
+// numbers, booleans, null, objects or arrays in JSON are sent as such, all others as strings
+func setFields(body []byte, fields []string) ([]byte, error) {
+	doc := map[string]interface{}{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return nil, errors.New("fields can only be set when the body is a JSON object")
+		}
+	}
+
+	for _, field := range fields {
+		key, value, found := strings.Cut(field, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid field '%v', use key=value", field)
+		}
+
+		var typed interface{} = value
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(value), &parsed); err == nil {
+			if _, isString := parsed.(string); !isString {
+				typed = parsed
+			}
+		}
+
+		parts := strings.Split(key, ".")
+		target := doc
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := target[part].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				target[part] = next
+			}
+			target = next
+		}
+		target[parts[len(parts)-1]] = typed
+	}
+
+	return json.Marshal(doc)
+}
+
+// Prints a JSON response in the chosen format; responses that are not JSON are printed as they are
+func printAPIResponse(response []byte, format string) error {
+	if len(bytes.TrimSpace(response)) == 0 {
+		return nil
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(response, &doc); err != nil {
+		fmt.Println(string(response))
+		return nil
+	}
+
+	var out []byte
+	var err error
+	if format == "yaml" {
+		out, err = yaml.Marshal(doc)
+	} else {
+		out, err = json.MarshalIndent(doc, "", "    ")
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(strings.TrimSuffix(string(out), "\n"))
+	return nil
+}
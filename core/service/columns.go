@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/olekukonko/tablewriter"
+
+	"dhcli/pkg/dhcore"
+	"dhcli/utils"
+)
+
+const customColumnsPrefix = "custom-columns="
+
+// Columns of the short output of list
+const defaultColumns = "NAME:.name,ID:.id,KIND:.kind,UPDATED:.metadata.updated,STATE:.status.state,LABELS:.metadata.labels"
+
+// A column of a table, with the path of the field it shows, such as .spec.path or .metadata.labels[0]
+type column struct {
+	header string
+	path   []string
+}
+
+// Returns the columns selected by the output format, or nil if it is not a table format; wide columns
+// are defined per resource in the configuration file, and default to the short ones
+func tableColumns(output string, resource string) ([]column, error) {
+	switch {
+	case strings.HasPrefix(output, customColumnsPrefix):
+		return parseColumns(strings.TrimPrefix(output, customColumnsPrefix))
+	case output == "wide":
+		if spec := utils.WideColumns(resource); spec != "" {
+			return parseColumns(spec)
+		}
+		return parseColumns(defaultColumns)
+	}
+	return nil, nil
+}
+
+// Parses a comma-separated list of HEADER:.path columns
+func parseColumns(spec string) ([]column, error) {
+	columns := []column{}
+	for _, def := range strings.Split(spec, ",") {
+		header, path, found := strings.Cut(strings.TrimSpace(def), ":")
+		if !found || header == "" || strings.Trim(path, ".") == "" {
+			return nil, fmt.Errorf("invalid column '%v', use HEADER:.path", def)
+		}
+
+		steps := []string{}
+		for _, part := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+			// Indexes are steps of their own: labels[0] is labels, then 0
+			name, rest, _ := strings.Cut(part, "[")
+			if name != "" {
+				steps = append(steps, name)
+			}
+			for rest != "" {
+				index, after, found := strings.Cut(rest, "]")
+				if _, err := strconv.Atoi(index); !found || err != nil {
+					return nil, fmt.Errorf("invalid index in column '%v'", def)
+				}
+				steps = append(steps, index)
+				rest = strings.TrimPrefix(after, "[")
+			}
+		}
+		columns = append(columns, column{header: header, path: steps})
+	}
+
+	return columns, nil
+}
+
+// Returns the value of the field at the path, formatted for a table cell; missing fields are empty
+func (c column) value(res dhcore.Resource) string {
+	var current interface{} = map[string]interface{}(res)
+	for _, step := range c.path {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			current = v[step]
+		case []interface{}:
+			i, err := strconv.Atoi(step)
+			if err != nil || i < 0 || i >= len(v) {
+				return ""
+			}
+			current = v[i]
+		default:
+			return ""
+		}
+	}
+
+	return formatCell(current)
+}
+
+// Lists of values, such as labels, are joined in cells
+func formatCell(value interface{}) string {
+	if list, ok := value.([]interface{}); ok {
+		items := []string{}
+		for _, item := range list {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				return formatValue(list)
+			}
+			items = append(items, formatValue(item))
+		}
+		return strings.Join(items, ", ")
+	}
+	return formatValue(value)
+}
+
+// Prints a table of the resources; without headers, rows are only aligned, for scripts to parse
+func printTable(resources []dhcore.Resource, columns []column, noHeaders bool) {
+	rows := [][]string{}
+	for _, res := range resources {
+		row := []string{}
+		for _, c := range columns {
+			row = append(row, c.value(res))
+		}
+		rows = append(rows, row)
+	}
+
+	if noHeaders {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		for _, row := range rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		w.Flush()
+		return
+	}
+
+	headers := []string{}
+	for _, c := range columns {
+		headers = append(headers, c.header)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header(headers)
+	for _, row := range rows {
+		table.Append(row)
+	}
+	table.Render()
+}
@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"sigs.k8s.io/yaml"
+
+	"dhcli/utils"
+)
+
+type configEntry struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source,omitempty"`
+}
+
+// Shows the effective configuration of an environment and, if resolved is set, the layer each value comes from
+func ConfigViewHandler(env string, envSource string, output string, project string, projectSource string, resolved bool) error {
+	_, section, err := utils.LoadIniConfig([]string{env})
+	if err != nil {
+		return err
+	}
+	format := utils.TranslateFormat(output)
+
+	if envSource == "" {
+		envSource = utils.EnvironmentSource()
+	}
+
+	entries := []configEntry{
+		{Key: "environment", Value: section.Name(), Source: envSource},
+		{Key: "project", Value: project, Source: projectSource},
+	}
+	for _, key := range section.Keys() {
+		entries = append(entries, configEntry{
+			Key:    key.Name(),
+			Value:  utils.MaskSecret(key.Name(), key.Value()),
+			Source: utils.KeySource(key),
+		})
+	}
+	if !resolved {
+		for i := range entries {
+			entries[i].Source = ""
+		}
+	}
+
+	switch format {
+	case "short":
+		header := []string{"KEY", "VALUE"}
+		if resolved {
+			header = append(header, "SOURCE")
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header(header)
+		for _, e := range entries {
+			row := []string{e.Key, e.Value}
+			if resolved {
+				row = append(row, e.Source)
+			}
+			table.Append(row)
+		}
+		table.Render()
+	case "json":
+		out, err := json.MarshalIndent(entries, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	case "yaml":
+		out, err := yaml.Marshal(entries)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"dhcli/utils"
+)
+
+var contextKeys = []string{"environment", "project", "output", "labels"}
+
+func ContextShowHandler(output string) error {
+	ctx, path, err := utils.LoadContext()
+	if err != nil {
+		return err
+	}
+	if _, found := utils.FindContextFile(); !found {
+		log.Println("No context file found.")
+		return nil
+	}
+
+	switch utils.TranslateFormat(output) {
+	case "short":
+		fmt.Printf("%-14s %v\n", "File:", path)
+		fmt.Printf("%-14s %v\n", "Environment:", ctx.Environment)
+		fmt.Printf("%-14s %v\n", "Project:", ctx.Project)
+		fmt.Printf("%-14s %v\n", "Output:", ctx.Output)
+		fmt.Printf("%-14s %v\n", "Labels:", strings.Join(ctx.Labels, ", "))
+	case "json":
+		out, err := json.MarshalIndent(ctx, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	case "yaml":
+		out, err := yaml.Marshal(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	}
+
+	return nil
+}
+
+func ContextSetHandler(key string, value string) error {
+	ctx, path, err := utils.LoadContext()
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(key) {
+	case "environment", "env":
+		ctx.Environment = value
+	case "project":
+		ctx.Project = value
+	case "output", "out":
+		ctx.Output = value
+	case "labels", "label":
+		ctx.Labels = nil
+		for _, label := range strings.Split(value, ",") {
+			if label = strings.TrimSpace(label); label != "" {
+				ctx.Labels = append(ctx.Labels, label)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown key '%v', supported keys are: %v", key, strings.Join(contextKeys, ", "))
+	}
+
+	if err := utils.SaveContext(ctx, path); err != nil {
+		return err
+	}
+	log.Printf("Context updated in %v.\n", path)
+	return nil
+}
+
+// Removes the given keys from the context, or the whole context file if none is given
+func ContextUnsetHandler(keys []string) error {
+	ctx, path, err := utils.LoadContext()
+	if err != nil {
+		return err
+	}
+	if _, found := utils.FindContextFile(); !found {
+		log.Println("No context file found.")
+		return nil
+	}
+
+	if len(keys) == 0 {
+		ctx = &utils.Context{}
+	}
+	for _, key := range keys {
+		switch strings.ToLower(key) {
+		case "environment", "env":
+			ctx.Environment = ""
+		case "project":
+			ctx.Project = ""
+		case "output", "out":
+			ctx.Output = ""
+		case "labels", "label":
+			ctx.Labels = nil
+		default:
+			return fmt.Errorf("unknown key '%v', supported keys are: %v", key, strings.Join(contextKeys, ", "))
+		}
+	}
+
+	if err := utils.SaveContext(ctx, path); err != nil {
+		return err
+	}
+	log.Printf("Context updated in %v.\n", path)
+	return nil
+}
@@ -5,56 +5,49 @@
 package service
 
 import (
+	"context"
+	"dhcli/pkg/dhcore"
 	"dhcli/utils"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"slices"
 
 	"sigs.k8s.io/yaml"
 )
 
-func CreateHandler(env string, project string, name string, filePath string, resetId bool, resource string) error {
-
-	endpoint := utils.TranslateEndpoint(resource)
+func CreateHandler(env string, project string, name string, filePath string, resetId bool, labels []string, resource string) error {
+	endpoint, err := utils.TranslateEndpoint(resource)
+	if err != nil {
+		return err
+	}
 
 	// Load environment and check API level requirements
-	cfg, section := utils.LoadIniConfig([]string{env})
-	utils.CheckUpdateEnvironment(cfg, section)
-	utils.CheckApiLevel(section, utils.CreateMin, utils.CreateMax)
+	client, _, err := utils.LoadCoreClient(env, utils.CreateMin, utils.CreateMax)
+	if err != nil {
+		return err
+	}
 
 	// Validate parameters
 	if endpoint != "projects" {
 		if project == "" {
-			log.Println("Project is mandatory when performing this operation on resources other than projects.")
-			os.Exit(1)
+			return errors.New("project is mandatory when performing this operation on resources other than projects")
 		}
 		if filePath == "" {
-			log.Println("Input file not specified.")
-			os.Exit(1)
+			return errors.New("input file not specified")
 		}
 	} else if filePath == "" && name == "" {
-		log.Println("Must provide either an input file or a name when creating a project.")
-		os.Exit(1)
+		return errors.New("must provide either an input file or a name when creating a project")
 	}
 
-	var jsonMap map[string]interface{}
+	var jsonMap dhcore.Resource
 
 	if filePath != "" {
-		// Read file
-		file, err := os.ReadFile(filePath)
-		if err != nil {
-			log.Printf("Failed to read YAML file: %v\n", err)
-			os.Exit(1)
-		}
-
-		// Convert YAML to JSON
-		jsonBytes, err := yaml.YAMLToJSON(file)
-
-		// Convert to map
-		err = json.Unmarshal(jsonBytes, &jsonMap)
+		jsonMap, err = readResourceFile(filePath)
 		if err != nil {
-			log.Printf("Failed to parse after JSON conversion: %v\n", err)
-			os.Exit(1)
+			return err
 		}
 
 		// Alter fields
@@ -68,26 +61,58 @@ func CreateHandler(env string, project string, name string, filePath string, res
 			delete(jsonMap, "id")
 		}
 	} else {
-		jsonMap = map[string]interface{}{}
+		jsonMap = dhcore.Resource{}
 		jsonMap["name"] = name
 	}
 
-	// Marshal
-	jsonBody, err := json.Marshal(jsonMap)
-	if err != nil {
-		log.Printf("Failed to marshal: %v\n", err)
-		os.Exit(1)
-	}
+	addLabels(jsonMap, labels)
 
 	// Request
-	method := "POST"
-	url := utils.BuildCoreUrl(section, project, endpoint, "", nil)
-	req := utils.PrepareRequest(method, url, jsonBody, section.Key("access_token").String())
-	_, err = utils.DoRequest(req)
-	if err != nil {
+	if _, err := client.Resources(project, dhcore.ResourceType(endpoint)).Create(context.Background(), jsonMap); err != nil {
 		return err
 	}
 
 	log.Println("Created successfully.")
 	return nil
 }
+
+// Reads a resource definition from a YAML or JSON file
+func readResourceFile(filePath string) (dhcore.Resource, error) {
+	file, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read YAML file: %w", err)
+	}
+
+	jsonBytes, err := yaml.YAMLToJSON(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert YAML to JSON: %w", err)
+	}
+
+	var jsonMap dhcore.Resource
+	if err := json.Unmarshal(jsonBytes, &jsonMap); err != nil {
+		return nil, fmt.Errorf("failed to parse after JSON conversion: %w", err)
+	}
+
+	return jsonMap, nil
+}
+
+// Adds labels to the resource metadata, skipping those already present
+func addLabels(jsonMap dhcore.Resource, labels []string) {
+	if len(labels) == 0 {
+		return
+	}
+
+	metadata, ok := jsonMap["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = map[string]interface{}{}
+		jsonMap["metadata"] = metadata
+	}
+
+	existing, _ := metadata["labels"].([]interface{})
+	for _, label := range labels {
+		if !slices.Contains(existing, interface{}(label)) {
+			existing = append(existing, label)
+		}
+	}
+	metadata["labels"] = existing
+}
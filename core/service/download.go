@@ -9,6 +9,7 @@ import (
 	"context"
 	s3client "dhcli/configs"
 	"dhcli/models"
+	"dhcli/pkg/dhcore"
 	"dhcli/utils"
 	"encoding/json"
 	"errors"
@@ -19,37 +20,43 @@ import (
 )
 
 func DownloadHandler(env string, output string, project string, name string, resource string, id string, originalArgs []string) error {
-	endpoint := utils.TranslateEndpoint(resource)
+	endpoint, err := utils.TranslateEndpoint(resource)
+	if err != nil {
+		return err
+	}
 
 	if endpoint != "projects" && project == "" {
 		return errors.New("project is mandatory when performing this operation on resources other than projects")
 	}
+	if id == "" && name == "" {
+		return errors.New("you must specify id or name")
+	}
 
-	params := map[string]string{}
-	if id == "" {
-		if name == "" {
-			return errors.New("you must specify id or name")
-		}
-		params["name"] = name
-		params["versions"] = "latest"
+	client, section, err := utils.LoadCoreClient(env, 0, 0)
+	if err != nil {
+		return err
 	}
 
-	_, section := utils.LoadIniConfig([]string{env})
-	method := "GET"
-	url := utils.BuildCoreUrl(section, project, endpoint, id, params)
-	req := utils.PrepareRequest(method, url, nil, section.Key("access_token").String())
-	body, err := utils.DoRequest(req)
+	resources := client.Resources(project, dhcore.ResourceType(endpoint))
+	var res dhcore.Resource
+	if id != "" {
+		res, err = resources.Get(context.Background(), id)
+	} else {
+		res, err = resources.GetLatest(context.Background(), name)
+	}
 	if err != nil {
 		return fmt.Errorf("error reading response: %w", err)
 	}
 
-	var resp models.Response[models.Artifact]
-	if err := json.Unmarshal(body, &resp); err != nil {
-		return fmt.Errorf("error unmarshalling JSON: %w", err)
+	var artifact models.Artifact
+	body, err := json.Marshal(res)
+	if err != nil {
+		return err
 	}
-	if len(resp.Content) == 0 {
-		return fmt.Errorf("no artifact was found in Content response")
+	if err := json.Unmarshal(body, &artifact); err != nil {
+		return fmt.Errorf("error unmarshalling JSON: %w", err)
 	}
+	resp := models.Response[models.Artifact]{Content: []models.Artifact{artifact}}
 
 	ctx := context.Background()
 	var s3Client *s3client.Client
@@ -85,6 +92,7 @@ func DownloadHandler(env string, output string, project string, name string, res
 					AccessToken: section.Key("aws_session_token").String(),
 					Region:      section.Key("aws_region").String(),
 					EndpointURL: section.Key("aws_endpoint_url").String(),
+					HTTPClient:  utils.StreamingHTTPClient(),
 				}
 				client, err := s3client.NewClient(ctx, cfg)
 				if err != nil {
@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+	"sigs.k8s.io/yaml"
+
+	"dhcli/utils"
+)
+
+const (
+	bundleKind    = "dhcli-environment"
+	bundleVersion = 1
+)
+
+// Portable description of an environment, as built by RegisterHandler
+type envBundle struct {
+	Kind    string            `json:"kind"`
+	Version int               `json:"version"`
+	Name    string            `json:"name"`
+	Config  map[string]string `json:"config"`
+}
+
+// Writes an environment bundle to a file, or to standard output; secrets are left out unless requested
+func ExportEnvHandler(env string, output string, filePath string, includeSecrets bool) error {
+	cfg, err := utils.LoadIni(false)
+	if err != nil {
+		return err
+	}
+	if env == "" {
+		env = cfg.Section("DEFAULT").Key(utils.CurrentEnvironment).String()
+		if env == "" {
+			return errors.New("environment was not passed and default environment is not specified in ini file")
+		}
+	}
+
+	section, err := cfg.GetSection(env)
+	if err != nil {
+		return fmt.Errorf("environment '%v' does not exist", env)
+	}
+	if includeSecrets {
+		utils.ResolveSecrets(section)
+	}
+
+	bundle := envBundle{Kind: bundleKind, Version: bundleVersion, Name: env, Config: map[string]string{}}
+	for _, key := range section.Keys() {
+		if !includeSecrets && (slices.Contains(utils.SecretKeys, key.Name()) || slices.Contains(utils.TokenKeys, key.Name())) {
+			continue
+		}
+		bundle.Config[key.Name()] = key.Value()
+	}
+
+	var out []byte
+	if utils.TranslateFormat(output) == "json" {
+		out, err = json.MarshalIndent(bundle, "", "    ")
+	} else {
+		out, err = yaml.Marshal(bundle)
+	}
+	if err != nil {
+		return err
+	}
+
+	if filePath == "" {
+		fmt.Println(string(out))
+		return nil
+	}
+
+	if err := os.WriteFile(filePath, out, 0600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	log.Printf("'%v' exported to %v.\n", env, filePath)
+	return nil
+}
+
+// Registers an environment from a bundle, checking it against the core it points to when reachable
+func ImportEnvHandler(filePath string, name string, overwrite bool) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	bundle := envBundle{}
+	if err := yaml.Unmarshal(data, &bundle); err != nil {
+		return fmt.Errorf("invalid bundle: %w", err)
+	}
+	if bundle.Kind != bundleKind {
+		return fmt.Errorf("invalid bundle: kind must be '%v'", bundleKind)
+	}
+	if bundle.Version != bundleVersion {
+		return fmt.Errorf("unsupported bundle version %v", bundle.Version)
+	}
+	endpoint := bundle.Config[utils.DhCoreEndpoint]
+	if endpoint == "" {
+		return errors.New("invalid bundle: core endpoint is missing")
+	}
+
+	if name == "" {
+		name = bundle.Name
+	}
+	if name == "" || name == "DEFAULT" {
+		return errors.New("environment name not specified and not defined in bundle")
+	}
+
+	cfg, err := utils.LoadIni(true)
+	if err != nil {
+		return err
+	}
+	if cfg.HasSection(name) && !overwrite {
+		return fmt.Errorf("environment '%v' already exists, use --name to import it under a different name, or --overwrite", name)
+	}
+
+	// Connection settings may come with the bundle
+	settings := ini.Empty().Section(name)
+	for _, k := range utils.TransportKeys {
+		if v, ok := bundle.Config[k]; ok {
+			settings.NewKey(k, v)
+		}
+	}
+	if err := utils.ConfigureHTTPClient(cfg, settings); err != nil {
+		return fmt.Errorf("invalid connection settings: %w", err)
+	}
+
+	validateBundle(bundle, endpoint)
+
+	section := cfg.Section(name)
+	utils.DeleteSecrets(section)
+	for _, k := range section.Keys() {
+		section.DeleteKey(k.Name())
+	}
+	for k, v := range bundle.Config {
+		section.NewKey(k, v)
+	}
+
+	defaultSection := cfg.Section("DEFAULT")
+	if !defaultSection.HasKey(utils.CurrentEnvironment) {
+		defaultSection.NewKey(utils.CurrentEnvironment, name)
+	}
+
+	if err := utils.SaveIni(cfg); err != nil {
+		return err
+	}
+
+	log.Printf("'%v' imported.\n", name)
+	return nil
+}
+
+// Compares the bundle with the live core configuration, which takes precedence on mismatches
+func validateBundle(bundle envBundle, endpoint string) {
+	config, err := utils.FetchConfig(strings.TrimSuffix(endpoint, "/") + "/.well-known/configuration")
+	if err != nil {
+		log.Printf("WARNING: Unable to validate bundle against core configuration: %v\n", err)
+		return
+	}
+
+	for k, v := range config {
+		key := k
+		if key == utils.ClientIdKey {
+			key = "client_id"
+		}
+
+		value := utils.ReflectValue(v)
+		if current, ok := bundle.Config[key]; ok && current != value {
+			log.Printf("WARNING: '%v' is '%v' in bundle, but '%v' in core configuration; the latter will be used.\n", key, current, value)
+		}
+		bundle.Config[key] = value
+	}
+	bundle.Config[utils.UpdatedEnvKey] = time.Now().Format(time.RFC3339)
+
+	checkRegisteredApiLevel(utils.GetStringValue(config, utils.ApiLevelKey))
+}
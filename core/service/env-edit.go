@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"gopkg.in/ini.v1"
+
+	"dhcli/utils"
+)
+
+// Renames an environment, moving its secrets and keeping it as default if it was
+func RenameEnvHandler(env string, newName string) error {
+	cfg, err := utils.LoadIni(false)
+	if err != nil {
+		return err
+	}
+	section, err := checkEnvNames(cfg, env, newName)
+	if err != nil {
+		return err
+	}
+
+	utils.ResolveSecrets(section)
+	copyEnvKeys(section, cfg.Section(newName), nil)
+	cfg.DeleteSection(env)
+
+	defaultSection := cfg.Section("DEFAULT")
+	if defaultSection.HasKey(utils.CurrentEnvironment) && defaultSection.Key(utils.CurrentEnvironment).String() == env {
+		defaultSection.Key(utils.CurrentEnvironment).SetValue(newName)
+	}
+
+	if err := utils.SaveIni(cfg); err != nil {
+		return err
+	}
+
+	// Secrets are stored under the new name by now
+	utils.DeleteSecrets(section)
+
+	log.Printf("'%v' renamed to '%v'.\n", env, newName)
+	return nil
+}
+
+// Copies an environment under a new name, without the tokens of its session
+func CopyEnvHandler(env string, newName string) error {
+	cfg, err := utils.LoadIni(false)
+	if err != nil {
+		return err
+	}
+	section, err := checkEnvNames(cfg, env, newName)
+	if err != nil {
+		return err
+	}
+
+	utils.ResolveSecrets(section)
+	copyEnvKeys(section, cfg.Section(newName), utils.TokenKeys)
+
+	if err := utils.SaveIni(cfg); err != nil {
+		return err
+	}
+	log.Printf("'%v' copied to '%v'.\n", env, newName)
+	return nil
+}
+
+// Sets a key of an environment, the default one if not specified
+func SetEnvKeyHandler(env string, key string, value string) error {
+	cfg, section, err := loadEnvSection(env)
+	if err != nil {
+		return err
+	}
+
+	utils.UpdateKey(section, key, value)
+
+	if err := utils.SaveIni(cfg); err != nil {
+		return err
+	}
+	log.Printf("'%v' set for '%v'.\n", key, section.Name())
+	return nil
+}
+
+// Removes keys from an environment, the default one if not specified
+func UnsetEnvKeysHandler(env string, keys []string) error {
+	cfg, section, err := loadEnvSection(env)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if !section.HasKey(key) {
+			log.Printf("WARNING: '%v' is not set for '%v'.\n", key, section.Name())
+			continue
+		}
+		utils.DeleteKey(section, key)
+	}
+
+	if err := utils.SaveIni(cfg); err != nil {
+		return err
+	}
+	log.Printf("Keys removed from '%v'.\n", section.Name())
+	return nil
+}
+
+// Returns the section of an existing environment, checking the name of the one to create from it is available
+func checkEnvNames(cfg *ini.File, env string, newName string) (*ini.Section, error) {
+	if newName == "" || newName == "DEFAULT" {
+		return nil, fmt.Errorf("invalid environment name '%v'", newName)
+	}
+	if env == "DEFAULT" || !cfg.HasSection(env) {
+		return nil, fmt.Errorf("environment '%v' does not exist", env)
+	}
+	if cfg.HasSection(newName) {
+		return nil, fmt.Errorf("environment '%v' already exists", newName)
+	}
+
+	return cfg.Section(env), nil
+}
+
+func copyEnvKeys(from *ini.Section, to *ini.Section, exclude []string) {
+	for _, key := range from.Keys() {
+		if !slices.Contains(exclude, key.Name()) {
+			to.NewKey(key.Name(), key.Value())
+		}
+	}
+}
+
+// Loads the ini file with the secrets of an environment resolved, falling back to the default environment
+func loadEnvSection(env string) (*ini.File, *ini.Section, error) {
+	cfg, err := utils.LoadIni(false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if env == "" {
+		if defaultSection := cfg.Section("DEFAULT"); defaultSection.HasKey(utils.CurrentEnvironment) {
+			env = defaultSection.Key(utils.CurrentEnvironment).String()
+		}
+		if env == "" {
+			return nil, nil, errors.New("environment was not passed and default environment is not specified in ini file")
+		}
+	}
+
+	if env == "DEFAULT" || !cfg.HasSection(env) {
+		return nil, nil, fmt.Errorf("environment '%v' does not exist", env)
+	}
+	section := cfg.Section(env)
+	utils.ResolveSecrets(section)
+
+	return cfg, section, nil
+}
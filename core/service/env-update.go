@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"fmt"
+	"log"
+
+	"dhcli/utils"
+)
+
+// Updates an environment from the core configuration and prints the keys that changed
+func UpdateEnvHandler(env string) error {
+	cfg, section, err := loadEnvSection(env)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.ConfigureHTTPClient(cfg, section); err != nil {
+		return fmt.Errorf("invalid connection settings: %w", err)
+	}
+
+	changes, err := utils.UpdateEnvironment(cfg, section)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		log.Printf("'%v' is up to date.\n", section.Name())
+		return nil
+	}
+
+	for _, c := range changes {
+		if c.Old == "" {
+			fmt.Printf("+ %v: %v\n", c.Key, utils.MaskSecret(c.Key, c.New))
+		} else {
+			fmt.Printf("~ %v: %v -> %v\n", c.Key, utils.MaskSecret(c.Key, c.Old), utils.MaskSecret(c.Key, c.New))
+		}
+		if c.Key == utils.ApiLevelKey {
+			checkRegisteredApiLevel(c.New)
+		}
+	}
+	log.Printf("'%v' updated.\n", section.Name())
+	return nil
+}
@@ -6,68 +6,86 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
-	"os"
-	"reflect"
 
 	"sigs.k8s.io/yaml"
 
+	"dhcli/pkg/dhcore"
 	"dhcli/utils"
 )
 
-func GetHandler(env string, output string, project string, name string, resource string, id string) error {
-
-	endpoint := utils.TranslateEndpoint(resource)
+func GetHandler(env string, output string, project string, name string, resource string, id string, noHeaders bool) error {
+	endpoint, err := utils.TranslateEndpoint(resource)
+	if err != nil {
+		return err
+	}
 
-	cfg, section := utils.LoadIniConfig([]string{env})
-	utils.CheckUpdateEnvironment(cfg, section)
-	utils.CheckApiLevel(section, utils.GetMin, utils.GetMax)
+	client, section, err := utils.LoadCoreClient(env, utils.GetMin, utils.GetMax)
+	if err != nil {
+		return err
+	}
 
 	format := utils.TranslateFormat(output)
+	columns, err := tableColumns(output, endpoint)
+	if err != nil {
+		return err
+	}
+	printer, err := newTemplatePrinter(output, false)
+	if err != nil {
+		return err
+	}
+	if columns == nil && printer == nil && !utils.KnownFormat(output) {
+		return fmt.Errorf("unknown output format '%v'", output)
+	}
 
 	if endpoint != "projects" && project == "" {
 		return errors.New("project is mandatory when working with resources other than projects")
 	}
 
-	params := map[string]string{}
-	if id == "" {
-		if name == "" {
-			return errors.New("you must specify id or name")
-		}
-		params["name"] = name
-		params["versions"] = "latest"
-	}
+	ctx := context.Background()
+	resources := client.Resources(project, dhcore.ResourceType(endpoint))
 
-	url := utils.BuildCoreUrl(section, project, endpoint, id, params)
-	req := utils.PrepareRequest("GET", url, nil, section.Key("access_token").String())
-	body, err := utils.DoRequest(req)
+	var raw json.RawMessage
+	if id != "" {
+		raw, err = resources.GetRaw(ctx, id)
+	} else if name != "" {
+		raw, err = resources.GetLatestRaw(ctx, name)
+	} else {
+		return errors.New("you must specify id or name")
+	}
 	if err != nil {
 		return fmt.Errorf("error in request: %w", err)
 	}
+	res := dhcore.Resource{}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return fmt.Errorf("json parsing failed: %w", err)
+	}
 
+	if columns != nil {
+		printTable([]dhcore.Resource{res}, columns, noHeaders)
+		return nil
+	}
+	if printer != nil {
+		return printer(templateData([]dhcore.Resource{res}, false))
+	}
 	switch format {
 	case "short":
-		return printShort(body)
+		printShort(res)
+		return nil
 	case "json":
-		return printJson(id, body)
+		return printJson(raw)
 	case "yaml":
 		utils.PrintCommentForYaml(section, env, resource, output, project, name, id)
-		return printYaml(id, body)
+		return printYaml(res)
 	default:
 		return fmt.Errorf("unknown format: %s", format)
 	}
 }
 
-func printShort(src []byte) error {
-	var obj map[string]interface{}
-	if err := json.Unmarshal(src, &obj); err != nil {
-		return err
-	}
-	m := getFirstIfList(obj)
-
+func printShort(m dhcore.Resource) {
 	fmt.Printf("%-12s %v\n", "Name:", m["name"])
 
 	if status, ok := m["status"].(map[string]interface{}); ok {
@@ -84,67 +102,23 @@ func printShort(src []byte) error {
 		fmt.Printf("%-12s %v\n", "Updated on:", meta["updated"])
 		fmt.Printf("%-12s %v\n", "Updated by:", meta["updated_by"])
 	}
-
-	return nil
 }
 
-func printJson(id string, src []byte) error {
-	var jsonData []byte = src
-	if id == "" {
-		var m map[string]interface{}
-		if err := json.Unmarshal(src, &m); err != nil {
-			return err
-		}
-		first := getFirstIfList(m)
-		out, err := json.Marshal(first)
-		if err != nil {
-			return err
-		}
-		jsonData = out
-	}
-
+// Prints the entity as sent by the core, keeping the order of its fields
+func printJson(src []byte) error {
 	var pretty bytes.Buffer
-	if err := json.Indent(&pretty, jsonData, "", "    "); err != nil {
+	if err := json.Indent(&pretty, src, "", "    "); err != nil {
 		return err
 	}
 	fmt.Println(pretty.String())
 	return nil
 }
 
-func printYaml(id string, src []byte) error {
-	var yamlData []byte
-
-	if id == "" {
-		var m map[string]interface{}
-		if err := json.Unmarshal(src, &m); err != nil {
-			return err
-		}
-		first := getFirstIfList(m)
-		out, err := yaml.Marshal(first)
-		if err != nil {
-			return err
-		}
-		yamlData = out
-	} else {
-		out, err := yaml.JSONToYAML(src)
-		if err != nil {
-			return err
-		}
-		yamlData = out
+func printYaml(res dhcore.Resource) error {
+	out, err := yaml.Marshal(res)
+	if err != nil {
+		return err
 	}
-
-	fmt.Println(string(yamlData))
+	fmt.Println(string(out))
 	return nil
 }
-
-func getFirstIfList(m map[string]interface{}) map[string]interface{} {
-	if content, ok := m["content"]; ok && reflect.ValueOf(content).Kind() == reflect.Slice {
-		contentSlice := content.([]interface{})
-		if len(contentSlice) >= 1 {
-			return contentSlice[0].(map[string]interface{})
-		}
-		log.Println("Error: resource not found")
-		os.Exit(1)
-	}
-	return m
-}
@@ -28,7 +28,10 @@ func InitEnvironmentHandler(env string, includePre bool) error {
 	}
 
 	// Read ini configuration
-	_, section := utils.LoadIniConfig([]string{env})
+	_, section, err := utils.LoadIniConfig([]string{env})
+	if err != nil {
+		return err
+	}
 
 	// Get minor version
 	apiVer := section.Key("dhcore_version").String()
@@ -5,63 +5,107 @@
 package service
 
 import (
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"os"
-	"reflect"
-	"strconv"
+	"slices"
+	"sort"
 	"strings"
 
-	"github.com/olekukonko/tablewriter"
-	"gopkg.in/ini.v1"
-
 	"sigs.k8s.io/yaml"
 
+	"dhcli/pkg/dhcore"
 	"dhcli/utils"
 )
 
-func ListResourcesHandler(env string, output string, project string, name string, kind string, state string, resource string) error {
-	endpoint := utils.TranslateEndpoint(resource)
+// Output formats of list only, printing one record per resource
+var recordFormats = []string{"csv", "tsv", "ndjson"}
+
+func ListResourcesHandler(env string, output string, project string, name string, kind string, state string, resource string, noHeaders bool) error {
+	endpoint, err := utils.TranslateEndpoint(resource)
+	if err != nil {
+		return err
+	}
 
-	cfg, section := utils.LoadIniConfig([]string{env})
-	utils.CheckUpdateEnvironment(cfg, section)
-	utils.CheckApiLevel(section, utils.ListMin, utils.ListMax)
+	client, section, err := utils.LoadCoreClient(env, utils.ListMin, utils.ListMax)
+	if err != nil {
+		return err
+	}
 
 	format := utils.TranslateFormat(output)
+	columns, err := tableColumns(output, endpoint)
+	if err != nil {
+		return err
+	}
+	printer, err := newTemplatePrinter(output, true)
+	if err != nil {
+		return err
+	}
+	recordFormat := strings.ToLower(output)
+	if !slices.Contains(recordFormats, recordFormat) {
+		recordFormat = ""
+	}
+	if columns == nil && printer == nil && recordFormat == "" && !utils.KnownFormat(output) {
+		return fmt.Errorf("unknown output format '%v'", output)
+	}
 
 	if endpoint != "projects" && project == "" {
 		return errors.New("project is mandatory when listing resources other than projects")
 	}
 
-	// Build query params
-	params := map[string]string{
-		"name":  name,
-		"kind":  kind,
-		"state": state,
-		"size":  "200",
-		"sort":  "updated,asc",
+	resources := client.Resources(project, dhcore.ResourceType(endpoint))
+	opts := dhcore.ListOptions{
+		Name:  name,
+		Kind:  kind,
+		State: state,
+		Sort:  "updated,asc",
 	}
-	if name != "" {
-		params["versions"] = "all"
+
+	// Each page is printed as soon as it is fetched
+	if recordFormat == "ndjson" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false)
+		err := resources.ListPages(context.Background(), opts, func(page []dhcore.Resource) error {
+			for _, res := range page {
+				if err := encoder.Encode(res); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fetch list: %w", err)
+		}
+		return nil
 	}
 
-	// Fetch first page
-	elements, _, err := fetchAllPages(section, project, endpoint, params)
+	elements, err := resources.List(context.Background(), opts)
 	if err != nil {
 		return fmt.Errorf("failed to fetch list: %w", err)
 	}
 
 	// Output
+	if columns != nil {
+		printTable(elements, columns, noHeaders)
+		return nil
+	}
+	if printer != nil {
+		return printer(templateData(elements, true))
+	}
+	if recordFormat != "" {
+		return printDelimitedList(elements, recordFormat == "tsv", noHeaders)
+	}
 	switch format {
 	case "short":
-		printShortList(elements)
+		printShortList(elements, noHeaders)
 	case "json":
-		printJSONList(elements)
+		return printJSONList(elements)
 	case "yaml":
 		utils.PrintCommentForYaml(section, env, resource, output, project, name, kind, state)
-		printYAMLList(elements)
+		return printYAMLList(elements)
 	default:
 		return fmt.Errorf("unknown format: %s", format)
 	}
@@ -69,94 +113,98 @@ func ListResourcesHandler(env string, output string, project string, name string
 	return nil
 }
 
-func fetchAllPages(section *ini.Section, project, endpoint string, params map[string]string) ([]interface{}, int, error) {
-	var (
-		elements   []interface{}
-		currentPg  int
-		totalPages int
-	)
-
-	for {
-		url := utils.BuildCoreUrl(section, project, endpoint, "", params)
-		req := utils.PrepareRequest("GET", url, nil, section.Key("access_token").String())
-		body, err := utils.DoRequest(req)
-		if err != nil {
-			return nil, 0, err
-		}
-
-		m := map[string]interface{}{}
-		if err := json.Unmarshal(body, &m); err != nil {
-			return nil, 0, fmt.Errorf("json parsing failed: %w", err)
-		}
-
-		pageList := m["content"].([]interface{})
-		elements = append(elements, pageList...)
-
-		pg := m["pageable"].(map[string]interface{})
-		currentPg = int(reflect.ValueOf(pg["pageNumber"]).Float())
-		totalPages = int(reflect.ValueOf(m["totalPages"]).Float())
+func printShortList(resources []dhcore.Resource, noHeaders bool) {
+	columns, _ := parseColumns(defaultColumns)
+	printTable(resources, columns, noHeaders)
+}
 
-		if currentPg >= totalPages-1 {
-			break
+// Prints resources as CSV or TSV, with a column for each field found in any of them; nested fields are
+// flattened, so that metadata.updated is a column of its own
+func printDelimitedList(resources []dhcore.Resource, tsv bool, noHeaders bool) error {
+	rows := []map[string]string{}
+	seen := map[string]bool{}
+	fields := []string{}
+	for _, res := range resources {
+		row := map[string]string{}
+		flattenFields("", map[string]interface{}(res), row)
+		for field := range row {
+			if !seen[field] {
+				seen[field] = true
+				fields = append(fields, field)
+			}
 		}
-		params["page"] = strconv.Itoa(currentPg + 1)
+		rows = append(rows, row)
 	}
 
-	return elements, totalPages, nil
-}
+	// Identifying fields first, the others in alphabetical order
+	leading := []string{"id", "name", "kind", "project"}
+	sort.Slice(fields, func(i, j int) bool {
+		li, lj := slices.Index(leading, fields[i]), slices.Index(leading, fields[j])
+		switch {
+		case li >= 0 && lj >= 0:
+			return li < lj
+		case li >= 0 || lj >= 0:
+			return li >= 0
+		}
+		return fields[i] < fields[j]
+	})
 
-func printShortList(resources []interface{}) {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.Header([]string{"NAME", "ID", "KIND", "UPDATED", "STATE", "LABELS"})
-
-	for _, ri := range resources {
-		m := ri.(map[string]interface{})
-		name := m["name"].(string)
-		id := m["id"].(string)
-		kind := m["kind"].(string)
-
-		updated := ""
-		labels := ""
-		if md, ok := m["metadata"].(map[string]interface{}); ok {
-			if u, ok := md["updated"].(string); ok {
-				updated = u
-			}
-			if lb, ok2 := md["labels"].([]interface{}); ok2 {
-				strs := []string{}
-				for _, v := range lb {
-					strs = append(strs, fmt.Sprint(v))
-				}
-				labels = strings.Join(strs, ", ")
-			}
+	records := [][]string{}
+	if !noHeaders {
+		records = append(records, fields)
+	}
+	for _, row := range rows {
+		record := make([]string, len(fields))
+		for i, field := range fields {
+			record[i] = row[field]
 		}
+		records = append(records, record)
+	}
 
-		state := ""
-		if st, ok := m["status"].(map[string]interface{}); ok {
-			if s, ok := st["state"].(string); ok {
-				state = s
+	if tsv {
+		// Tabs and line breaks in values are escaped, so that each record stays on a line
+		escaper := strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r")
+		for _, record := range records {
+			for i := range record {
+				record[i] = escaper.Replace(record[i])
 			}
+			fmt.Println(strings.Join(record, "\t"))
 		}
+		return nil
+	}
 
-		table.Append([]string{name, id, kind, updated, state, labels})
+	w := csv.NewWriter(os.Stdout)
+	if err := w.WriteAll(records); err != nil {
+		return fmt.Errorf("error writing CSV: %w", err)
 	}
+	return nil
+}
 
-	table.Render()
+// Adds the fields of an object to row, with dotted names for nested objects; lists are kept as values
+func flattenFields(prefix string, object map[string]interface{}, row map[string]string) {
+	for key, value := range object {
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenFields(prefix+key+".", nested, row)
+			continue
+		}
+		row[prefix+key] = formatCell(value)
+	}
 }
 
-func printJSONList(resources []interface{}) {
+func printJSONList(resources []dhcore.Resource) error {
 	out, err := json.MarshalIndent(resources, "", "    ")
 	if err != nil {
-		log.Printf("Error serializing JSON: %v", err)
-		os.Exit(1)
+		return fmt.Errorf("error serializing JSON: %w", err)
 	}
 	fmt.Println(string(out))
+	return nil
 }
 
-func printYAMLList(resources []interface{}) {
+func printYAMLList(resources []dhcore.Resource) error {
 	out, err := yaml.Marshal(resources)
 	if err != nil {
-		log.Printf("Error serializing YAML: %v", err)
-		os.Exit(1)
+		return fmt.Errorf("error serializing YAML: %w", err)
 	}
 	fmt.Println(string(out))
+	return nil
 }
@@ -5,28 +5,159 @@
 package service
 
 import (
-	"dhcli/utils"
+	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"gopkg.in/ini.v1"
+	"sigs.k8s.io/yaml"
+
+	"dhcli/utils"
 )
 
-func ListEnvHandler() {
-	cfg := utils.LoadIni(true)
-	sections := cfg.SectionStrings()
-	sectionsString := ""
+type envSummary struct {
+	Name           string `json:"name"`
+	Current        bool   `json:"current"`
+	Endpoint       string `json:"endpoint"`
+	CoreVersion    string `json:"core_version"`
+	ApiLevel       string `json:"api_level"`
+	Updated        string `json:"updated"`
+	LoggedIn       bool   `json:"logged_in"`
+	TokenExpiresAt string `json:"token_expires_at,omitempty"`
+	TokenExpired   bool   `json:"token_expired"`
+}
+
+func ListEnvHandler(output string) error {
+	cfg, err := utils.LoadIni(true)
+	if err != nil {
+		return err
+	}
+	format := utils.TranslateFormat(output)
 
-	for _, name := range sections {
-		if name != "DEFAULT" {
-			sectionsString += name + ", "
+	current := ""
+	if defaultSection := cfg.Section("DEFAULT"); defaultSection.HasKey(utils.CurrentEnvironment) {
+		current = defaultSection.Key(utils.CurrentEnvironment).String()
+	}
+
+	envs := []envSummary{}
+	for _, section := range cfg.Sections() {
+		if section.Name() != "DEFAULT" {
+			envs = append(envs, summarizeEnv(section, section.Name() == current))
 		}
 	}
 
-	if sectionsString == "" {
+	if len(envs) == 0 && format == "short" {
 		log.Println("No environments available.")
-		return
+		return nil
+	}
+
+	switch format {
+	case "short":
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header([]string{"", "NAME", "ENDPOINT", "VERSION", "API LEVEL", "UPDATED", "LOGGED IN", "TOKEN EXPIRES"})
+		for _, e := range envs {
+			marker := ""
+			if e.Current {
+				marker = "*"
+			}
+			loggedIn := "no"
+			if e.LoggedIn {
+				loggedIn = "yes"
+			}
+			expires := e.TokenExpiresAt
+			if e.TokenExpired {
+				expires += " (expired)"
+			}
+			table.Append([]string{marker, e.Name, e.Endpoint, e.CoreVersion, e.ApiLevel, e.Updated, loggedIn, expires})
+		}
+		table.Render()
+	case "json":
+		out, err := json.MarshalIndent(envs, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	case "yaml":
+		out, err := yaml.Marshal(envs)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+
+	return nil
+}
+
+func summarizeEnv(section *ini.Section, current bool) envSummary {
+	value := func(key string) string {
+		if section.HasKey(key) {
+			return section.Key(key).Value()
+		}
+		return ""
+	}
+
+	e := envSummary{
+		Name:        section.Name(),
+		Current:     current,
+		Endpoint:    value(utils.DhCoreEndpoint),
+		CoreVersion: value("dhcore_version"),
+		ApiLevel:    value(utils.ApiLevelKey),
+		Updated:     value(utils.UpdatedEnvKey),
+		LoggedIn:    value("access_token") != "",
+	}
+
+	if expiry, ok := utils.AccessTokenExpiry(section); ok && e.LoggedIn {
+		e.TokenExpiresAt = expiry.Format(time.RFC3339)
+		e.TokenExpired = expiry.Before(time.Now())
+	}
+
+	return e
+}
+
+// Shows all the keys of an environment, with secrets masked
+func ShowEnvHandler(env string, output string) error {
+	cfg, err := utils.LoadIni(false)
+	if err != nil {
+		return err
+	}
+	if env == "" {
+		env = cfg.Section("DEFAULT").Key(utils.CurrentEnvironment).String()
+		if env == "" {
+			return fmt.Errorf("environment was not passed and default environment is not specified in ini file")
+		}
+	}
+
+	section, err := cfg.GetSection(env)
+	if err != nil {
+		return fmt.Errorf("environment '%v' does not exist", env)
+	}
+
+	switch utils.TranslateFormat(output) {
+	case "short":
+		for _, key := range section.Keys() {
+			fmt.Printf("%-30s %v\n", key.Name(), utils.MaskSecret(key.Name(), key.Value()))
+		}
+	case "json", "yaml":
+		m := map[string]string{}
+		for _, key := range section.Keys() {
+			m[key.Name()] = utils.MaskSecret(key.Name(), key.Value())
+		}
+		out, err := json.MarshalIndent(m, "", "    ")
+		if err != nil {
+			return err
+		}
+		if utils.TranslateFormat(output) == "yaml" {
+			if out, err = yaml.JSONToYAML(out); err != nil {
+				return err
+			}
+		}
+		fmt.Println(string(out))
 	}
-	sectionsString = sectionsString[:len(sectionsString)-2]
 
-	log.Println("Available environments:")
-	fmt.Printf("%v\n", sectionsString)
+	return nil
 }
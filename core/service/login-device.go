@@ -0,0 +1,209 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+
+	"dhcli/utils"
+)
+
+const (
+	deviceCodeGrantType   = "urn:ietf:params:oauth:grant-type:device_code"
+	defaultPollInterval   = 5
+	slowDownIntervalDelta = 5
+)
+
+type deviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type tokenError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Runs the OAuth 2.0 device authorization grant (RFC 8628); waiting for the user is bound by timeout,
+// and stops when ctx is cancelled
+func deviceLogin(ctx context.Context, cfg *ini.File, section *ini.Section, timeout time.Duration) error {
+	deviceEndpoint, err := discoverDeviceEndpoint(cfg, section)
+	if err != nil {
+		return err
+	}
+
+	auth, err := requestDeviceAuthorization(deviceEndpoint, section)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("─────────────────────────────────────────────────────────────────────")
+	fmt.Println("🔐  To authenticate, open the following URL on any device:")
+	fmt.Println("─────────────────────────────────────────────────────────────────────")
+	fmt.Println(auth.VerificationURI)
+	fmt.Println("─────────────────────────────────────────────────────────────────────")
+	fmt.Printf("and enter the code: %v\n", auth.UserCode)
+	if auth.VerificationURIComplete != "" {
+		fmt.Println("─────────────────────────────────────────────────────────────────────")
+		fmt.Println("Alternatively, open this URL which already contains the code:")
+		fmt.Println(auth.VerificationURIComplete)
+	}
+	fmt.Println("─────────────────────────────────────────────────────────────────────")
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	tkn, err := pollDeviceToken(ctx, section, auth)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("login timed out after %v", timeout)
+	}
+	if errors.Is(err, context.Canceled) {
+		return errors.New("login cancelled")
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := utils.StoreTokens(cfg, section, tkn); err != nil {
+		return err
+	}
+
+	log.Println("Login successful.")
+	return nil
+}
+
+// Returns the device authorization endpoint, fetching the OpenID configuration if it was not stored yet
+func discoverDeviceEndpoint(cfg *ini.File, section *ini.Section) (string, error) {
+	endpoint, err := discoverAuthEndpoint(cfg, section, "device_authorization_endpoint")
+	if err != nil {
+		return "", err
+	}
+	if endpoint == "" {
+		return "", errors.New("the authorization server does not support the device authorization grant")
+	}
+
+	return endpoint, nil
+}
+
+func requestDeviceAuthorization(deviceEndpoint string, section *ini.Section) (*deviceAuthorization, error) {
+	v := url.Values{
+		"client_id": {section.Key("client_id").String()},
+	}
+	if scope := strings.ReplaceAll(section.Key("scopes_supported").String(), ",", " "); scope != "" {
+		v.Set("scope", scope)
+	}
+
+	resp, err := utils.HTTPClient().PostForm(deviceEndpoint, v)
+	if err != nil {
+		return nil, fmt.Errorf("device authorization request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("device authorization error %s: %s", resp.Status, body)
+	}
+
+	auth := &deviceAuthorization{}
+	if err := json.Unmarshal(body, auth); err != nil {
+		return nil, fmt.Errorf("invalid device authorization response: %w", err)
+	}
+	if auth.DeviceCode == "" || auth.UserCode == "" || auth.VerificationURI == "" {
+		return nil, errors.New("device authorization response is missing required fields")
+	}
+	if auth.Interval <= 0 {
+		auth.Interval = defaultPollInterval
+	}
+
+	return auth, nil
+}
+
+// Polls the token endpoint until the user completes the authorization, the device code expires or ctx is done
+func pollDeviceToken(ctx context.Context, section *ini.Section, auth *deviceAuthorization) ([]byte, error) {
+	interval := time.Duration(auth.Interval) * time.Second
+	var deadline time.Time
+	if auth.ExpiresIn > 0 {
+		deadline = time.Now().Add(time.Duration(auth.ExpiresIn) * time.Second)
+	}
+
+	v := url.Values{
+		"grant_type":  {deviceCodeGrantType},
+		"device_code": {auth.DeviceCode},
+		"client_id":   {section.Key("client_id").String()},
+	}
+
+	log.Println("Waiting for authorization...")
+	for {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return nil, errors.New("device code expired before authorization was completed")
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", section.Key("token_endpoint").String(), strings.NewReader(v.Encode()))
+		if err != nil {
+			return nil, fmt.Errorf("token request error: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := utils.HTTPClient().Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("token request error: %w", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading response: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			return body, nil
+		}
+
+		failure := &utils.TokenError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
+		var tErr tokenError
+		if err := json.Unmarshal(body, &tErr); err != nil {
+			return nil, failure
+		}
+
+		switch tErr.Error {
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval += slowDownIntervalDelta * time.Second
+		case "access_denied":
+			failure.Message = "authorization was denied"
+			return nil, failure
+		case "expired_token":
+			failure.Message = "device code expired before authorization was completed"
+			return nil, failure
+		default:
+			failure.Message = fmt.Sprintf("token error %s: %s %s", resp.Status, tErr.Error, tErr.ErrorDescription)
+			return nil, failure
+		}
+	}
+}
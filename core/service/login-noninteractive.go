@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+
+	"dhcli/utils"
+)
+
+// Runs the client credentials grant, reading missing credentials from the environment variables
+func clientCredentialsLogin(cfg *ini.File, section *ini.Section, clientId string, clientSecret string) error {
+	if clientId == "" {
+		clientId = os.Getenv(utils.ClientIdEnvVar)
+	}
+	if clientSecret == "" {
+		clientSecret = os.Getenv(utils.ClientSecretEnvVar)
+	}
+	if clientId == "" || clientSecret == "" {
+		return fmt.Errorf("client id and secret are required, either as flags or through %v and %v", utils.ClientIdEnvVar, utils.ClientSecretEnvVar)
+	}
+
+	tokenEndpoint := section.Key("token_endpoint").String()
+	if tokenEndpoint == "" {
+		return errors.New("token endpoint is not configured for this environment")
+	}
+
+	v := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {clientId},
+		"client_secret": {clientSecret},
+	}
+	if scope := strings.ReplaceAll(section.Key("scopes_supported").String(), ",", " "); scope != "" {
+		v.Set("scope", scope)
+	}
+
+	resp, err := utils.HTTPClient().PostForm(tokenEndpoint, v)
+	if err != nil {
+		return fmt.Errorf("token request error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &utils.TokenError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(body, &m); err != nil {
+		return fmt.Errorf("invalid token response: %w", err)
+	}
+
+	// Client credentials usually come without a refresh token: drop the one of any previous session
+	if _, ok := m["refresh_token"]; !ok {
+		m["refresh_token"] = ""
+	}
+
+	if err := utils.StoreTokenMap(cfg, section, m); err != nil {
+		return err
+	}
+
+	log.Println("Login successful.")
+	return nil
+}
+
+// Stores a personal access token read from standard input
+func tokenLogin(cfg *ini.File, section *ini.Section) error {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Print("Paste your access token: ")
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("error in reading token: %w", err)
+	}
+	token := strings.TrimSpace(line)
+	if token == "" {
+		return errors.New("no token provided")
+	}
+
+	m := map[string]interface{}{
+		"access_token":       token,
+		"refresh_token":      "",
+		"expires_in":         "",
+		"refresh_expires_in": "",
+	}
+	if expiry, ok := utils.TokenExpiry(token); ok {
+		if !expiry.After(time.Now()) {
+			return fmt.Errorf("token expired on %v", expiry.Format(time.RFC3339))
+		}
+		m["expires_in"] = int(time.Until(expiry).Seconds())
+	}
+
+	if err := utils.StoreTokenMap(cfg, section, m); err != nil {
+		return err
+	}
+
+	log.Println("Token stored.")
+	return nil
+}
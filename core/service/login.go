@@ -7,43 +7,84 @@ package service
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
+	"os/signal"
 	"runtime"
-	"slices"
 	"strings"
+	"time"
 
 	"gopkg.in/ini.v1"
 
 	"dhcli/utils"
 )
 
-const redirectURI = "http://localhost:4000/callback"
+const (
+	// Port registered as redirect URI for the CLI client; 0 selects an ephemeral port
+	DefaultCallbackPort = 4000
+	DefaultLoginTimeout = 5 * time.Minute
 
-var generatedState string
+	shutdownTimeout = 5 * time.Second
+)
 
-// Runs PKCE flow for authentication
-func LoginHandler(env string) error {
-	cfg, section := loadIniCfg(env)
+type callbackServer struct {
+	srv         *http.Server
+	redirectURI string
+	result      chan error
+}
+
+// Runs PKCE flow for authentication, unless a non-interactive grant is requested
+func LoginHandler(env string, device bool, clientCredentials bool, clientId string, clientSecret string, token bool, port int, timeout time.Duration) error {
+	cfg, section, err := utils.LoadIniConfig([]string{env})
+	if err != nil {
+		return err
+	}
 
 	utils.CheckUpdateEnvironment(cfg, section)
-	utils.CheckApiLevel(section, utils.LoginMin, utils.LoginMax)
+	if err := utils.CheckApiLevel(section, utils.LoginMin, utils.LoginMax); err != nil {
+		return err
+	}
+	if utils.AuthDisabled(section) {
+		return errors.New("environment does not use authentication")
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultLoginTimeout
+	}
+	interrupted, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	switch {
+	case device:
+		return deviceLogin(interrupted, cfg, section, timeout)
+	case clientCredentials:
+		return clientCredentialsLogin(cfg, section, clientId, clientSecret)
+	case token:
+		return tokenLogin(cfg, section)
+	}
 
 	cv, cc := generatePKCE()
-	generatedState = randomString(32)
+	state := randomString(32)
 
-	startAuthCodeServer(cfg, section, cv)
+	server, err := startAuthCodeServer(cfg, section, cv, state, port)
+	if err != nil {
+		return err
+	}
+	defer server.shutdown()
 
-	authURL := buildAuthURL(section, cc, generatedState)
+	authURL := buildAuthURL(section, server.redirectURI, cc, state)
 
 	fmt.Println("─────────────────────────────────────────────────────────────────────")
 	fmt.Println("🔐  The following URL will be opened in your browser to authenticate:")
@@ -52,21 +93,43 @@ func LoginHandler(env string) error {
 	fmt.Println("─────────────────────────────────────────────────────────────────────")
 	fmt.Print("Press Enter to continue... ")
 
-	_, err := bufio.NewReader(os.Stdin).ReadBytes('\n')
-	if err != nil {
-		fmt.Printf("Error while authenticating: %v", err)
-		return err
+	// The prompt is read in the background, so that Ctrl-C is not held until Enter is pressed
+	entered := make(chan error, 1)
+	go func() {
+		_, err := bufio.NewReader(os.Stdin).ReadBytes('\n')
+		entered <- err
+	}()
+	select {
+	case err := <-entered:
+		if err != nil {
+			return fmt.Errorf("error while authenticating: %w", err)
+		}
+	case <-interrupted.Done():
+		return errors.New("login cancelled")
 	}
 
+	// The time limit applies to the browser flow, not to the wait at the prompt
+	ctx, cancel := context.WithTimeout(interrupted, timeout)
+	defer cancel()
+
 	if err := openBrowser(authURL); err != nil {
 		log.Printf("Error opening browser: %v", err)
 	}
 
-	select {} // lock the program to wait for user interaction
-}
-
-func loadIniCfg(env string) (*ini.File, *ini.Section) {
-	return utils.LoadIniConfig([]string{env})
+	// Wait for the browser to reach the callback
+	select {
+	case err := <-server.result:
+		if err != nil {
+			return err
+		}
+		log.Println("Login successful.")
+		return nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("login timed out after %v", timeout)
+		}
+		return errors.New("login cancelled")
+	}
 }
 
 func generatePKCE() (verifier, challenge string) {
@@ -90,28 +153,53 @@ func randomStringCharset(n int, cs string) string {
 	return string(b)
 }
 
-func startAuthCodeServer(cfg *ini.File, section *ini.Section, verifier string) {
-	http.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+// Starts a loopback server receiving the authorization code; its outcome is sent on the result channel
+func startAuthCodeServer(cfg *ini.File, section *ini.Section, verifier string, state string, port int) (*callbackServer, error) {
+	ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return nil, fmt.Errorf("unable to start callback server on port %v: %w", port, err)
+	}
+
+	server := &callbackServer{
+		redirectURI: fmt.Sprintf("http://localhost:%d/callback", ln.Addr().(*net.TCPAddr).Port),
+		result:      make(chan error, 1),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
 		authCode := r.URL.Query().Get("code")
-		state := r.URL.Query().Get("state")
 
-		if state != generatedState {
+		if r.URL.Query().Get("state") != state {
 			http.Error(w, "Invalid state", http.StatusBadRequest)
-			log.Fatalf("State mismatch: got %q", state)
+			server.done(fmt.Errorf("state mismatch: got %q", r.URL.Query().Get("state")))
+			return
+		}
+		if authErr := r.URL.Query().Get("error"); authErr != "" {
+			http.Error(w, "Authorization failed", http.StatusBadRequest)
+			server.done(fmt.Errorf("authorization failed: %v %v", authErr, r.URL.Query().Get("error_description")))
+			return
 		}
 		if authCode == "" {
 			http.Error(w, "Missing code", http.StatusBadRequest)
 			return
 		}
 
-		tkn := exchangeAuthCode(
+		tkn, err := exchangeAuthCode(
 			section.Key("token_endpoint").String(),
 			section.Key("client_id").String(),
+			server.redirectURI,
 			verifier,
 			authCode,
 		)
-		if tkn == nil {
+		if err != nil {
 			http.Error(w, "Failed token exchange", http.StatusInternalServerError)
+			server.done(fmt.Errorf("failed token exchange: %w", err))
+			return
+		}
+
+		if err := utils.StoreTokens(cfg, section, tkn); err != nil {
+			http.Error(w, "Failed to store tokens", http.StatusInternalServerError)
+			server.done(fmt.Errorf("failed to store tokens: %w", err))
 			return
 		}
 
@@ -129,26 +217,36 @@ func startAuthCodeServer(cfg *ini.File, section *ini.Section, verifier string) {
 		fmt.Fprintf(w, "<pre id=\"resp\" style=\"background:#f6f8fa;border:1px solid #ccc;padding:16px;width:800px;overflow:auto;\">%s</pre>", prettyJSON.String())
 		fmt.Fprintln(w, "</div>")
 
-		var m map[string]interface{}
-		json.Unmarshal(tkn, &m)
-		for k, v := range m {
-			if !slices.Contains([]string{"client_id", "token_type", "id_token"}, k) {
-				utils.UpdateKey(section, k, fmt.Sprint(v))
-			}
-		}
-		utils.UpdateKey(section, "access_token", fmt.Sprint(m["access_token"]))
-		if rt, ok := m["refresh_token"]; ok {
-			utils.UpdateKey(section, "refresh_token", fmt.Sprint(rt))
+		server.done(nil)
+	})
+
+	server.srv = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			server.done(fmt.Errorf("callback server failed: %w", err))
 		}
-		utils.SaveIni(cfg)
+	}()
 
-		log.Println("Login successful.")
-		go os.Exit(0)
-	})
-	go http.ListenAndServe(":4000", nil)
+	return server, nil
+}
+
+// Reports the outcome of the login, keeping only the first one
+func (s *callbackServer) done(err error) {
+	select {
+	case s.result <- err:
+	default:
+	}
+}
+
+func (s *callbackServer) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := s.srv.Shutdown(ctx); err != nil {
+		s.srv.Close()
+	}
 }
 
-func exchangeAuthCode(tokenURL, clientID, verifier, code string) []byte {
+func exchangeAuthCode(tokenURL, clientID, redirectURI, verifier, code string) ([]byte, error) {
 	v := url.Values{
 		"grant_type":    {"authorization_code"},
 		"client_id":     {clientID},
@@ -156,22 +254,22 @@ func exchangeAuthCode(tokenURL, clientID, verifier, code string) []byte {
 		"code":          {code},
 		"redirect_uri":  {redirectURI},
 	}
-	resp, err := http.PostForm(tokenURL, v)
+	resp, err := utils.HTTPClient().PostForm(tokenURL, v)
 	if err != nil {
-		log.Printf("Token request error: %v", err)
-		return nil
+		return nil, fmt.Errorf("token request error: %w", err)
 	}
 	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Token error %s: %s", resp.Status, body)
-		return nil
+		return nil, &utils.TokenError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
 	}
-	tkn, _ := io.ReadAll(resp.Body)
-	return tkn
+	return body, nil
 }
 
-func buildAuthURL(section *ini.Section, chal, state string) string {
+func buildAuthURL(section *ini.Section, redirectURI, chal, state string) string {
 	v := url.Values{
 		"response_type":         {"code"},
 		"client_id":             {section.Key("client_id").String()},
@@ -184,6 +282,34 @@ func buildAuthURL(section *ini.Section, chal, state string) string {
 	return section.Key("authorization_endpoint").String() + "?" + v.Encode() + "&scope=" + scope
 }
 
+// Returns an endpoint of the authorization server stored under key, such as userinfo_endpoint. Environments
+// registered before the CLI used it lack it: it is then read from the OpenID configuration and saved. The
+// result is empty if the authorization server does not provide the endpoint.
+func discoverAuthEndpoint(cfg *ini.File, section *ini.Section, key string) (string, error) {
+	// Checked first, as reading a missing key adds it to the section, to be saved empty
+	if section.HasKey(key) && section.Key(key).String() != "" {
+		return section.Key(key).String(), nil
+	}
+
+	baseEndpoint := strings.TrimSuffix(section.Key(utils.DhCoreEndpoint).String(), "/")
+	openIdConfig, err := utils.FetchConfig(baseEndpoint + "/.well-known/openid-configuration")
+	if err != nil {
+		return "", fmt.Errorf("fetching OpenID configuration failed: %w", err)
+	}
+
+	endpoint := utils.GetStringValue(openIdConfig, key)
+	if endpoint == "" {
+		return "", nil
+	}
+
+	utils.UpdateKey(section, key, endpoint)
+	if err := utils.SaveIni(cfg); err != nil {
+		return "", err
+	}
+
+	return endpoint, nil
+}
+
 func openBrowser(u string) error {
 	var cmd *exec.Cmd
 	switch runtime.GOOS {
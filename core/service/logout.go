@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+
+	"gopkg.in/ini.v1"
+
+	"dhcli/utils"
+)
+
+// Ends the session of an environment (or all of them), revoking its tokens when the provider supports it
+func LogoutHandler(env string, all bool) error {
+	if !all {
+		cfg, section, err := utils.LoadIniConfig([]string{env})
+		if err != nil {
+			return err
+		}
+		utils.CheckUpdateEnvironment(cfg, section)
+
+		logout(cfg, section)
+		if err := utils.SaveIni(cfg); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	cfg, err := utils.LoadIni(false)
+	if err != nil {
+		return err
+	}
+	for _, section := range cfg.Sections() {
+		if section.Name() == "DEFAULT" {
+			continue
+		}
+		utils.ResolveSecrets(section)
+
+		// Revocation requests are sent with the connection settings of each environment
+		if err := utils.ConfigureHTTPClient(cfg, section); err != nil {
+			log.Printf("WARNING: '%v': %v, tokens will only be removed locally.\n", section.Name(), err)
+			utils.ClearTokens(section)
+			continue
+		}
+		logout(cfg, section)
+	}
+	if err := utils.SaveIni(cfg); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func logout(cfg *ini.File, section *ini.Section) {
+	accessToken := section.Key("access_token").String()
+	refreshToken := section.Key("refresh_token").String()
+	if accessToken == "" && refreshToken == "" {
+		log.Printf("'%v': not logged in.\n", section.Name())
+		utils.ClearTokens(section)
+		return
+	}
+
+	revocationEndpoint, err := discoverAuthEndpoint(cfg, section, "revocation_endpoint")
+	if err != nil {
+		log.Printf("WARNING: '%v': token revocation is unavailable (%v), tokens will only be removed locally.\n", section.Name(), err)
+	} else if revocationEndpoint == "" {
+		log.Printf("WARNING: '%v': provider does not support token revocation, tokens will only be removed locally.\n", section.Name())
+	} else {
+		// Revoking the refresh token first also ends the session on most providers
+		for _, t := range []struct{ hint, token string }{{"refresh_token", refreshToken}, {"access_token", accessToken}} {
+			if t.token == "" {
+				continue
+			}
+			if err := revokeToken(revocationEndpoint, section.Key("client_id").String(), t.token, t.hint); err != nil {
+				log.Printf("WARNING: '%v': failed to revoke %v: %v\n", section.Name(), t.hint, err)
+			}
+		}
+	}
+
+	utils.ClearTokens(section)
+	log.Printf("'%v': logged out.\n", section.Name())
+}
+
+// Sends a token revocation request (RFC 7009)
+func revokeToken(endpoint string, clientId string, token string, hint string) error {
+	v := url.Values{
+		"token":           {token},
+		"token_type_hint": {hint},
+		"client_id":       {clientId},
+	}
+
+	resp, err := utils.HTTPClient().PostForm(endpoint, v)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("revocation endpoint responded with %s: %s", resp.Status, body)
+	}
+
+	return nil
+}
@@ -5,38 +5,25 @@
 package service
 
 import (
+	"context"
 	"dhcli/utils"
-	"errors"
-	"fmt"
 	"log"
-	"slices"
-	"strings"
 )
 
-var validOperations = []string{"stop", "run", "resume", "delete", "build"}
-
 func OperateRunHandler(env string, project string, id string, operation string) error {
 	// Check that CLI has permission to handle runs
-	endpoint := utils.TranslateEndpoint("runs")
-
-	// Check that operation is valid
-	op := strings.ToLower(operation)
-	if !slices.Contains(validOperations, op) {
-		return errors.New(fmt.Sprintf("Operation '%v' not supported. Supported operations: %v", op, strings.Join(validOperations, ", ")))
+	if _, err := utils.TranslateEndpoint("runs"); err != nil {
+		return err
 	}
 
 	// Load environment and check API level requirements
-	cfg, section := utils.LoadIniConfig([]string{env})
-	utils.CheckUpdateEnvironment(cfg, section)
-	utils.CheckApiLevel(section, utils.OperateRunMin, utils.OperateRunMax)
+	client, _, err := utils.LoadCoreClient(env, utils.OperateRunMin, utils.OperateRunMax)
+	if err != nil {
+		return err
+	}
 
 	// Request
-	method := "POST"
-	url := utils.BuildCoreUrl(section, project, endpoint, id, nil) + "/" + op
-	req := utils.PrepareRequest(method, url, nil, section.Key("access_token").String())
-
-	_, err := utils.DoRequest(req)
-	if err != nil {
+	if err := client.Runs(project).Operate(context.Background(), id, operation); err != nil {
 		return err
 	}
 	log.Println("Operation successful.")
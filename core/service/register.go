@@ -5,15 +5,23 @@
 package service
 
 import (
+	"bufio"
 	"dhcli/utils"
 	"fmt"
 	"log"
+	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+
+	"gopkg.in/ini.v1"
 )
 
-func RegisterHandler(env string, endpoint string) error {
+// Settings of the user rather than of the core, kept when an environment is registered again
+var preservedKeys = append([]string{utils.UpdateIntervalKey}, utils.TransportKeys...)
+
+func RegisterHandler(env string, endpoint string, manual bool, noAuth bool, apiVersion string, apiLevel string, issuer string, clientId string) error {
 	if endpoint == "" {
 		return fmt.Errorf("endpoint is required")
 	}
@@ -21,12 +29,30 @@ func RegisterHandler(env string, endpoint string) error {
 		endpoint += "/"
 	}
 
-	cfg := utils.LoadIni(true)
-
-	// 1. Fetch core config
-	config, err := utils.FetchConfig(endpoint + ".well-known/configuration")
+	cfg, err := utils.LoadIni(true)
 	if err != nil {
-		return fmt.Errorf("fetching configuration failed: %w", err)
+		return err
+	}
+
+	// Connection settings of an environment being registered again, or shared ones
+	var existing *ini.Section
+	if env != "" && cfg.HasSection(env) {
+		existing = cfg.Section(env)
+	}
+	if err := utils.ConfigureHTTPClient(cfg, existing); err != nil {
+		return fmt.Errorf("invalid connection settings: %w", err)
+	}
+
+	// 1. Fetch core config, or build it from flags and prompts
+	var config map[string]interface{}
+	if manual {
+		config = manualConfig(&env, endpoint, noAuth, apiVersion, apiLevel, &issuer, clientId)
+	} else {
+		c, err := utils.FetchConfig(endpoint + ".well-known/configuration")
+		if err != nil {
+			return fmt.Errorf("fetching configuration failed (use --manual to register without it): %w", err)
+		}
+		config = c
 	}
 
 	if env == "" || env == "null" {
@@ -36,16 +62,28 @@ func RegisterHandler(env string, endpoint string) error {
 		}
 	}
 
-	// 2. Clear section if it exists
+	// 2. Fetch OpenID config, before stored secrets of the section are cleared
+	var openIdConfig map[string]interface{}
+	if !noAuth {
+		c, err := fetchOpenIdConfig(endpoint, manual, issuer)
+		if err != nil {
+			return fmt.Errorf("fetching OpenID configuration failed: %w", err)
+		}
+		openIdConfig = c
+	}
+
+	// 3. Clear section if it exists
 	if cfg.HasSection(env) {
 		log.Printf("Section '%v' already exists, will be overwritten.\n", env)
 	}
 	section := cfg.Section(env)
 	for _, k := range section.Keys() {
-		section.DeleteKey(k.Name())
+		if !slices.Contains(preservedKeys, k.Name()) {
+			utils.DeleteKey(section, k.Name())
+		}
 	}
 
-	// 3. Reflect config keys
+	// 4. Reflect config keys
 	for k, v := range config {
 		key := k
 		if key == utils.ClientIdKey {
@@ -54,39 +92,118 @@ func RegisterHandler(env string, endpoint string) error {
 		section.NewKey(key, utils.ReflectValue(v))
 	}
 
-	// 4. Check API level
-	apiLevel := utils.GetStringValue(config, utils.ApiLevelKey)
-	apiLevelInt, err := strconv.Atoi(apiLevel)
-	if err != nil {
-		log.Println("WARNING: API level not valid or missing.")
-	} else if apiLevelInt < utils.MinApiLevel {
-		log.Printf("WARNING: API level %v < minimum required %v\n", apiLevelInt, utils.MinApiLevel)
-	}
+	// 5. Check API level
+	checkRegisteredApiLevel(utils.GetStringValue(config, utils.ApiLevelKey))
 
-	// 5. Fetch and reflect OpenID config
-	openIdConfig, err := utils.FetchConfig(endpoint + ".well-known/openid-configuration")
-	if err != nil {
-		return fmt.Errorf("fetching OpenID configuration failed: %w", err)
-	}
-	for _, k := range utils.OpenIdFields {
-		var v interface{} = ""
-		if val, ok := openIdConfig[k]; ok {
-			v = val
+	// 6. Reflect OpenID config
+	if noAuth {
+		section.NewKey(utils.AuthKey, utils.AuthNone)
+	} else {
+		for _, k := range utils.OpenIdFields {
+			var v interface{} = ""
+			if val, ok := openIdConfig[k]; ok {
+				v = val
+			}
+			section.NewKey(k, utils.ReflectValue(v))
 		}
-		section.NewKey(k, utils.ReflectValue(v))
 	}
 
-	// 6. Add timestamp
+	// Configuration cannot be fetched again: do not try to update it
+	if manual && !section.HasKey(utils.UpdateIntervalKey) {
+		section.NewKey(utils.UpdateIntervalKey, "never")
+	}
+
+	// 7. Add timestamp
 	section.NewKey(utils.UpdatedEnvKey, time.Now().Format(time.RFC3339))
 
-	// 7. Set default env if missing
+	// 8. Set default env if missing
 	defaultSection := cfg.Section("DEFAULT")
 	if !defaultSection.HasKey(utils.CurrentEnvironment) {
 		defaultSection.NewKey(utils.CurrentEnvironment, env)
 	}
 
-	utils.SaveIni(cfg)
+	if err := utils.SaveIni(cfg); err != nil {
+		return err
+	}
 
 	log.Printf("'%v' registered.\n", env)
 	return nil
 }
+
+// Builds the core configuration from flags, prompting for missing values when run in a terminal
+func manualConfig(env *string, endpoint string, noAuth bool, apiVersion string, apiLevel string, issuer *string, clientId string) map[string]interface{} {
+	reader := bufio.NewReader(os.Stdin)
+	interactive := false
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		interactive = true
+	}
+
+	prompt := func(value string, label string, def string) string {
+		if value != "" || !interactive {
+			if value == "" {
+				return def
+			}
+			return value
+		}
+		if def != "" {
+			fmt.Printf("%v [%v]: ", label, def)
+		} else {
+			fmt.Printf("%v: ", label)
+		}
+		line, _ := reader.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+		return def
+	}
+
+	*env = prompt(*env, "Environment name", "")
+	config := map[string]interface{}{
+		utils.DhCoreEndpoint: strings.TrimSuffix(endpoint, "/"),
+		utils.ApiVersionKey:  prompt(apiVersion, "API version", "v1"),
+		utils.ApiLevelKey:    prompt(apiLevel, "API level", strconv.Itoa(utils.MinApiLevel)),
+	}
+	if *env != "" {
+		config["dhcore_name"] = *env
+	}
+
+	if !noAuth {
+		*issuer = prompt(*issuer, "Issuer", "")
+		config[utils.ClientIdKey] = prompt(clientId, "Client id", "")
+	}
+
+	return config
+}
+
+// Fetches the OpenID configuration from the core, or from the issuer when registering manually
+func fetchOpenIdConfig(endpoint string, manual bool, issuer string) (map[string]interface{}, error) {
+	if !manual {
+		return utils.FetchConfig(endpoint + ".well-known/openid-configuration")
+	}
+
+	if issuer == "" {
+		log.Println("WARNING: Issuer not specified, login will not be available.")
+		return map[string]interface{}{}, nil
+	}
+
+	openIdConfig, err := utils.FetchConfig(strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration")
+	if err != nil {
+		log.Printf("WARNING: Unable to fetch OpenID configuration of issuer, login will not be available: %v\n", err)
+		openIdConfig = map[string]interface{}{}
+	}
+	if _, ok := openIdConfig["issuer"]; !ok {
+		openIdConfig["issuer"] = issuer
+	}
+
+	return openIdConfig, nil
+}
+
+// Warns if the API level of a core being registered is missing or too old
+func checkRegisteredApiLevel(apiLevel string) {
+	apiLevelInt, err := strconv.Atoi(apiLevel)
+	if err != nil {
+		log.Println("WARNING: API level not valid or missing.")
+	} else if apiLevelInt < utils.MinApiLevel {
+		log.Printf("WARNING: API level %v < minimum required %v\n", apiLevelInt, utils.MinApiLevel)
+	}
+}
@@ -7,25 +7,31 @@ package service
 import (
 	"dhcli/utils"
 	"log"
-	"os"
 )
 
-func RemoveHandler(env string) {
+func RemoveHandler(env string) error {
 	sectionName := env
 
-	cfg := utils.LoadIni(false)
+	cfg, err := utils.LoadIni(false)
+	if err != nil {
+		return err
+	}
 	if !cfg.HasSection(sectionName) {
 		log.Printf("Specified environment does not exist.\n")
-		os.Exit(0)
+		return nil
 	}
 
+	utils.DeleteSecrets(cfg.Section(sectionName))
 	cfg.DeleteSection(sectionName)
 
 	defaultSection := cfg.Section("DEFAULT")
-	if defaultSection.Key("current_environment").String() == sectionName {
-		defaultSection.DeleteKey("current_environment")
+	if defaultSection.HasKey(utils.CurrentEnvironment) && defaultSection.Key(utils.CurrentEnvironment).String() == sectionName {
+		defaultSection.DeleteKey(utils.CurrentEnvironment)
 	}
 
-	utils.SaveIni(cfg)
+	if err := utils.SaveIni(cfg); err != nil {
+		return err
+	}
 	log.Printf("'%v' has been removed.\n", sectionName)
+	return nil
 }
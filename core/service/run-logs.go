@@ -5,35 +5,27 @@
 package service
 
 import (
+	"context"
 	"dhcli/utils"
-	"encoding/json"
-	"fmt"
 )
 
 func RunLogsHandler(env string, project string, id string) error {
 	// Check that CLI has permission to handle runs
-	endpoint := utils.TranslateEndpoint("runs")
+	if _, err := utils.TranslateEndpoint("runs"); err != nil {
+		return err
+	}
 
 	// Load environment and check API level requirements
-	cfg, section := utils.LoadIniConfig([]string{env})
-	utils.CheckUpdateEnvironment(cfg, section)
-	utils.CheckApiLevel(section, utils.RunLogsMin, utils.RunLogsMax)
+	client, _, err := utils.LoadCoreClient(env, utils.RunLogsMin, utils.RunLogsMax)
+	if err != nil {
+		return err
+	}
 
 	// Request
-	method := "GET"
-	url := utils.BuildCoreUrl(section, project, endpoint, id, nil) + "/logs"
-	req := utils.PrepareRequest(method, url, nil, section.Key("access_token").String())
-
-	body, err := utils.DoRequest(req)
+	logs, err := client.Runs(project).Logs(context.Background(), id)
 	if err != nil {
 		return err
 	}
-	logs := []interface{}{}
-	if err := json.Unmarshal(body, &logs); err != nil {
-		return fmt.Errorf("json parsing failed: %w", err)
-	}
-
-	printJSONList(logs)
 
-	return nil
+	return printJSONList(logs)
 }
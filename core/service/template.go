@@ -0,0 +1,284 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"dhcli/pkg/dhcore"
+)
+
+const (
+	jsonPathPrefix       = "jsonpath="
+	goTemplatePrefix     = "go-template="
+	goTemplateFilePrefix = "go-template-file="
+)
+
+// Prints the data of a command in a template format
+type templatePrinter func(data interface{}) error
+
+// Returns the printer of a jsonpath or go-template output format, or nil for other formats. Templates are
+// parsed here, so that errors in them are reported before any request is sent.
+func newTemplatePrinter(output string, list bool) (templatePrinter, error) {
+	switch {
+	case strings.HasPrefix(output, jsonPathPrefix):
+		expr := strings.TrimPrefix(output, jsonPathPrefix)
+		if !strings.Contains(expr, "{") {
+			// A bare path, such as status.state, prints the field of each entity on its own line
+			expr = "{." + strings.TrimPrefix(expr, ".") + "}{\"\\n\"}"
+			if list {
+				expr = "{range .items[*]}" + expr + "{end}"
+			}
+		}
+		nodes, err := parseJSONPath(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jsonpath: %w", err)
+		}
+		return func(data interface{}) error {
+			var b strings.Builder
+			if err := executeJSONPath(&b, nodes, data, data); err != nil {
+				return err
+			}
+			fmt.Print(b.String())
+			return nil
+		}, nil
+
+	case strings.HasPrefix(output, goTemplatePrefix), strings.HasPrefix(output, goTemplateFilePrefix):
+		text := strings.TrimPrefix(output, goTemplatePrefix)
+		if strings.HasPrefix(output, goTemplateFilePrefix) {
+			b, err := os.ReadFile(strings.TrimPrefix(output, goTemplateFilePrefix))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read template: %w", err)
+			}
+			text = string(b)
+		}
+		tmpl, err := template.New("output").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+		return func(data interface{}) error {
+			return tmpl.Execute(os.Stdout, data)
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// Data templates are applied to: a single entity, or the items of a list
+func templateData(resources []dhcore.Resource, list bool) interface{} {
+	if !list {
+		return map[string]interface{}(resources[0])
+	}
+	items := make([]interface{}, len(resources))
+	for i, res := range resources {
+		items[i] = map[string]interface{}(res)
+	}
+	return map[string]interface{}{"items": items}
+}
+
+// A node of a jsonpath template: text, an expression, or a range over an expression
+type jsonPathNode struct {
+	text     string
+	expr     []string
+	isRange  bool
+	children []jsonPathNode
+}
+
+// Parses the subset of kubectl's jsonpath used to extract values: {.field}, {.list[0]}, {.list[*]}, {$.field},
+// {range <expr>}...{end} and {"literal"}, mixed with text
+func parseJSONPath(expr string) ([]jsonPathNode, error) {
+	root := []jsonPathNode{}
+	stack := []*[]jsonPathNode{&root}
+	ranges := []jsonPathNode{}
+
+	for expr != "" {
+		current := stack[len(stack)-1]
+		start := strings.Index(expr, "{")
+		if start < 0 {
+			*current = append(*current, jsonPathNode{text: expr})
+			break
+		}
+		if start > 0 {
+			*current = append(*current, jsonPathNode{text: expr[:start]})
+		}
+		end := strings.Index(expr[start:], "}")
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed action in '%v'", expr)
+		}
+		action := strings.TrimSpace(expr[start+1 : start+end])
+		expr = expr[start+end+1:]
+
+		switch {
+		case action == "end":
+			if len(stack) == 1 {
+				return nil, errors.New("{end} without {range}")
+			}
+			node := ranges[len(ranges)-1]
+			node.children = *stack[len(stack)-1]
+			ranges = ranges[:len(ranges)-1]
+			stack = stack[:len(stack)-1]
+			parent := stack[len(stack)-1]
+			*parent = append(*parent, node)
+		case strings.HasPrefix(action, "range "):
+			steps, err := parseJSONPathExpr(strings.TrimPrefix(action, "range "))
+			if err != nil {
+				return nil, err
+			}
+			ranges = append(ranges, jsonPathNode{expr: steps, isRange: true})
+			children := []jsonPathNode{}
+			stack = append(stack, &children)
+		case strings.HasPrefix(action, `"`):
+			text, err := strconv.Unquote(action)
+			if err != nil {
+				return nil, fmt.Errorf("invalid literal %v", action)
+			}
+			*current = append(*current, jsonPathNode{text: text})
+		default:
+			steps, err := parseJSONPathExpr(action)
+			if err != nil {
+				return nil, err
+			}
+			*current = append(*current, jsonPathNode{expr: steps})
+		}
+	}
+	if len(stack) > 1 {
+		return nil, errors.New("{range} without {end}")
+	}
+
+	return root, nil
+}
+
+// Splits an expression into steps: field names, indexes and *; a leading $ step refers to the root
+func parseJSONPathExpr(expr string) ([]string, error) {
+	steps := []string{}
+	if strings.HasPrefix(expr, "$") {
+		steps = append(steps, "$")
+		expr = expr[1:]
+	} else if strings.HasPrefix(expr, "@") {
+		expr = expr[1:]
+	}
+
+	for expr != "" {
+		switch expr[0] {
+		case '.':
+			expr = expr[1:]
+			n := strings.IndexAny(expr, ".[")
+			if n < 0 {
+				n = len(expr)
+			}
+			if n > 0 {
+				steps = append(steps, expr[:n])
+			}
+			expr = expr[n:]
+		case '[':
+			n := strings.Index(expr, "]")
+			if n < 0 {
+				return nil, fmt.Errorf("unclosed [ in '%v'", expr)
+			}
+			// Quoted names, such as ['created_by'], are fields; otherwise indexes or *
+			raw := expr[1:n]
+			index := strings.Trim(raw, `'"`)
+			if _, err := strconv.Atoi(index); err != nil && index == raw && index != "*" {
+				return nil, fmt.Errorf("invalid index [%v]", raw)
+			}
+			steps = append(steps, index)
+			expr = expr[n+1:]
+		default:
+			return nil, fmt.Errorf("invalid expression '%v', paths start with a dot", expr)
+		}
+	}
+
+	return steps, nil
+}
+
+func executeJSONPath(b *strings.Builder, nodes []jsonPathNode, current interface{}, root interface{}) error {
+	for _, node := range nodes {
+		switch {
+		case node.isRange:
+			for _, item := range evalJSONPath(node.expr, current, root) {
+				if err := executeJSONPath(b, node.children, item, root); err != nil {
+					return err
+				}
+			}
+		case node.expr != nil:
+			values := []string{}
+			for _, value := range evalJSONPath(node.expr, current, root) {
+				values = append(values, formatValue(value))
+			}
+			b.WriteString(strings.Join(values, " "))
+		default:
+			b.WriteString(node.text)
+		}
+	}
+	return nil
+}
+
+// Returns the values the steps lead to; missing fields lead to no value
+func evalJSONPath(steps []string, current interface{}, root interface{}) []interface{} {
+	values := []interface{}{current}
+	for _, step := range steps {
+		if step == "$" {
+			values = []interface{}{root}
+			continue
+		}
+
+		next := []interface{}{}
+		for _, value := range values {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				if step == "*" {
+					keys := make([]string, 0, len(v))
+					for key := range v {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						next = append(next, v[key])
+					}
+				} else if field, ok := v[step]; ok {
+					next = append(next, field)
+				}
+			case []interface{}:
+				if step == "*" {
+					next = append(next, v...)
+				} else if i, err := strconv.Atoi(step); err == nil {
+					if i < 0 {
+						i += len(v)
+					}
+					if i >= 0 && i < len(v) {
+						next = append(next, v[i])
+					}
+				}
+			}
+		}
+		values = next
+	}
+
+	return values
+}
+
+// Formats a value of an entity for text output; objects and lists are printed as JSON
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
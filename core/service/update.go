@@ -5,54 +5,42 @@
 package service
 
 import (
+	"context"
+	"dhcli/pkg/dhcore"
 	"dhcli/utils"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
-	"os"
-
-	"sigs.k8s.io/yaml"
 )
 
 func UpdateHandler(env string, project string, filePath string, resource string, id string) error {
-
-	endpoint := utils.TranslateEndpoint(resource)
+	endpoint, err := utils.TranslateEndpoint(resource)
+	if err != nil {
+		return err
+	}
 
 	// Load environment and check API level requirements
-	cfg, section := utils.LoadIniConfig([]string{env})
-	utils.CheckUpdateEnvironment(cfg, section)
-	utils.CheckApiLevel(section, utils.UpdateMin, utils.UpdateMax)
+	client, _, err := utils.LoadCoreClient(env, utils.UpdateMin, utils.UpdateMax)
+	if err != nil {
+		return err
+	}
 
 	if filePath == "" {
-		log.Println("Input file not specified.")
-		os.Exit(1)
+		return errors.New("input file not specified")
 	}
 
 	if endpoint != "projects" && project == "" {
-		log.Println("Project is mandatory when performing this operation on resources other than projects.")
-		os.Exit(1)
+		return errors.New("project is mandatory when performing this operation on resources other than projects")
 	}
-	// Read a file
-	file, err := os.ReadFile(filePath)
-	if err != nil {
-		log.Printf("Failed to read YAML file: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Convert YAML to JSON
-	jsonBytes, err := yaml.YAMLToJSON(file)
 
-	// Convert to map
-	var jsonMap map[string]interface{}
-	err = json.Unmarshal(jsonBytes, &jsonMap)
+	jsonMap, err := readResourceFile(filePath)
 	if err != nil {
-		log.Printf("Failed to parse after JSON conversion: %v\n", err)
-		os.Exit(1)
+		return err
 	}
 
 	// Alter fields
 	if jsonMap["id"] != nil && jsonMap["id"] != id {
-		log.Printf("Error: specified ID (%v) and ID found in file (%v) do not match. Are you sure you are trying to update the correct resource?\n", id, jsonMap["id"])
-		os.Exit(1)
+		return fmt.Errorf("specified ID (%v) and ID found in file (%v) do not match, are you sure you are trying to update the correct resource?", id, jsonMap["id"])
 	}
 
 	delete(jsonMap, "user")
@@ -61,20 +49,8 @@ func UpdateHandler(env string, project string, filePath string, resource string,
 		jsonMap["project"] = project
 	}
 
-	// Marshal back
-	jsonBody, err := json.Marshal(jsonMap)
-	if err != nil {
-		log.Printf("Failed to marshal: %v\n", err)
-		os.Exit(1)
-	}
-
 	// Request
-	method := "PUT"
-	url := utils.BuildCoreUrl(section, project, endpoint, id, nil)
-	req := utils.PrepareRequest(method, url, jsonBody, section.Key("access_token").String())
-
-	_, err = utils.DoRequest(req)
-	if err != nil {
+	if _, err := client.Resources(project, dhcore.ResourceType(endpoint)).Update(context.Background(), id, jsonMap); err != nil {
 		return err
 	}
 	log.Println("Updated successfully.")
@@ -6,21 +6,26 @@ package service
 
 import (
 	"dhcli/utils"
+	"errors"
 	"log"
-	"os"
 )
 
-func UseHandler(env string) {
+func UseHandler(env string) error {
 	environmentName := env
-	cfg := utils.LoadIni(false)
+	cfg, err := utils.LoadIni(false)
+	if err != nil {
+		return err
+	}
 	if !cfg.HasSection(environmentName) {
-		log.Printf("Specified environment does not exist.\n")
-		os.Exit(1)
+		return errors.New("specified environment does not exist")
 	}
 
 	defaultSection := cfg.Section("DEFAULT")
 	defaultSection.Key("current_environment").SetValue(environmentName)
 
-	utils.SaveIni(cfg)
+	if err := utils.SaveIni(cfg); err != nil {
+		return err
+	}
 	log.Printf("Switched default to '%v'.\n", environmentName)
+	return nil
 }
@@ -0,0 +1,219 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+	"sigs.k8s.io/yaml"
+
+	"dhcli/utils"
+)
+
+// Refresh tokens expiring sooner than this trigger a warning
+const refreshExpiryWarning = time.Hour
+
+type whoamiInfo struct {
+	Environment      string                 `json:"environment"`
+	Subject          string                 `json:"subject,omitempty"`
+	Username         string                 `json:"username,omitempty"`
+	Email            string                 `json:"email,omitempty"`
+	Roles            []string               `json:"roles,omitempty"`
+	Scopes           []string               `json:"scopes,omitempty"`
+	IssuedAt         string                 `json:"issued_at,omitempty"`
+	ExpiresAt        string                 `json:"expires_at,omitempty"`
+	Expired          bool                   `json:"expired"`
+	RefreshExpiresAt string                 `json:"refresh_expires_at,omitempty"`
+	Claims           map[string]interface{} `json:"claims,omitempty"`
+	UserInfo         map[string]interface{} `json:"userinfo,omitempty"`
+}
+
+func WhoamiHandler(env string, output string, userinfo bool) error {
+	cfg, section, err := utils.LoadIniConfig([]string{env})
+	if err != nil {
+		return err
+	}
+	format := utils.TranslateFormat(output)
+
+	if utils.AuthDisabled(section) {
+		return errors.New("environment does not use authentication")
+	}
+
+	accessToken := section.Key("access_token").String()
+	if accessToken == "" {
+		return utils.ErrNotLoggedIn
+	}
+
+	info := whoamiInfo{Environment: section.Name()}
+
+	claims, err := utils.ParseJWTClaims(accessToken)
+	if err == nil {
+		info.Claims = claims
+		info.Subject = utils.GetStringValue(claims, "sub")
+		info.Username = firstClaim(claims, "preferred_username", "username", "name")
+		info.Email = utils.GetStringValue(claims, "email")
+		info.Roles = claimRoles(claims)
+		info.Scopes = claimScopes(claims)
+		info.IssuedAt = claimTime(claims, "iat")
+		info.ExpiresAt = claimTime(claims, "exp")
+	} else {
+		log.Println("Access token is not a JWT, claims are not available.")
+	}
+
+	// Opaque tokens: rely on the expiration stored at login
+	if info.ExpiresAt == "" {
+		info.ExpiresAt = section.Key(utils.ExpiresAtKey).String()
+	}
+	if expiry, err := time.Parse(time.RFC3339, info.ExpiresAt); err == nil {
+		info.Expired = expiry.Before(time.Now())
+	}
+
+	if refreshExpiry, ok := refreshTokenExpiry(section); ok {
+		info.RefreshExpiresAt = refreshExpiry.Format(time.RFC3339)
+		if remaining := time.Until(refreshExpiry); remaining <= 0 {
+			log.Println("WARNING: Refresh token has expired, please log in again.")
+		} else if remaining < refreshExpiryWarning {
+			log.Printf("WARNING: Refresh token expires in %v, you will need to log in again soon.\n", remaining.Round(time.Second))
+		}
+	}
+
+	if userinfo {
+		endpoint, err := discoverAuthEndpoint(cfg, section, "userinfo_endpoint")
+		if err != nil {
+			return err
+		}
+		if endpoint == "" {
+			return errors.New("the authorization server does not provide a userinfo endpoint")
+		}
+		req, err := utils.PrepareRequest("GET", endpoint, nil, accessToken)
+		if err != nil {
+			return err
+		}
+		body, err := utils.DoRequest(req)
+		if err != nil {
+			return fmt.Errorf("error in request: %w", err)
+		}
+		if err := json.Unmarshal(body, &info.UserInfo); err != nil {
+			return fmt.Errorf("json parsing failed: %w", err)
+		}
+	}
+
+	switch format {
+	case "short":
+		printWhoamiShort(info)
+	case "json":
+		out, err := json.MarshalIndent(info, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	case "yaml":
+		out, err := yaml.Marshal(info)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+
+	return nil
+}
+
+func printWhoamiShort(info whoamiInfo) {
+	fmt.Printf("%-16s %v\n", "Environment:", info.Environment)
+	fmt.Printf("%-16s %v\n", "Subject:", info.Subject)
+	fmt.Printf("%-16s %v\n", "Username:", info.Username)
+	if info.Email != "" {
+		fmt.Printf("%-16s %v\n", "Email:", info.Email)
+	}
+	fmt.Printf("%-16s %v\n", "Roles:", strings.Join(info.Roles, ", "))
+	fmt.Printf("%-16s %v\n", "Scopes:", strings.Join(info.Scopes, ", "))
+	fmt.Printf("%-16s %v\n", "Issued at:", info.IssuedAt)
+	expiresAt := info.ExpiresAt
+	if info.Expired {
+		expiresAt += " (expired)"
+	}
+	fmt.Printf("%-16s %v\n", "Expires at:", expiresAt)
+	if info.RefreshExpiresAt != "" {
+		fmt.Printf("%-16s %v\n", "Refresh until:", info.RefreshExpiresAt)
+	}
+
+	keys := make([]string, 0, len(info.UserInfo))
+	for k := range info.UserInfo {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("%-16s %v\n", "Userinfo "+k+":", info.UserInfo[k])
+	}
+}
+
+// Returns the expiration of the refresh token, from its claims or from the value stored at login
+func refreshTokenExpiry(section *ini.Section) (time.Time, bool) {
+	refreshToken := section.Key("refresh_token").String()
+	if refreshToken == "" {
+		return time.Time{}, false
+	}
+	if expiry, ok := utils.TokenExpiry(refreshToken); ok {
+		return expiry, true
+	}
+
+	expiry, err := time.Parse(time.RFC3339, section.Key(utils.RefreshExpiresAtKey).String())
+	return expiry, err == nil
+}
+
+func firstClaim(claims map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if v := utils.GetStringValue(claims, k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func claimTime(claims map[string]interface{}, key string) string {
+	if v, ok := claims[key].(float64); ok {
+		return time.Unix(int64(v), 0).Format(time.RFC3339)
+	}
+	return ""
+}
+
+// Collects roles from the claims used by the supported identity providers
+func claimRoles(claims map[string]interface{}) []string {
+	roles := toStrings(claims["roles"])
+	roles = append(roles, toStrings(claims["authorities"])...)
+	if realm, ok := claims["realm_access"].(map[string]interface{}); ok {
+		roles = append(roles, toStrings(realm["roles"])...)
+	}
+	return roles
+}
+
+func claimScopes(claims map[string]interface{}) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	return toStrings(claims["scp"])
+}
+
+func toStrings(v interface{}) []string {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	s := []string{}
+	for _, element := range list {
+		s = append(s, fmt.Sprint(element))
+	}
+	return s
+}
@@ -0,0 +1,245 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dhcore is a client for the API of the DigitalHub core.
+//
+//	client, err := dhcore.NewClient(dhcore.Config{
+//		Endpoint:    "https://core.example.com",
+//		TokenSource: dhcore.StaticToken(token),
+//	})
+//	functions, err := client.Functions("my-project").List(ctx, dhcore.ListOptions{})
+package dhcore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const DefaultAPIVersion = "v1"
+
+// TokenSource provides the access token sent with each request
+type TokenSource interface {
+	// Token returns the current token, refreshing it first if it is known to be expired
+	Token(ctx context.Context) (string, error)
+
+	// Refresh obtains a new token after the current one was rejected
+	Refresh(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource for a token that cannot be refreshed
+type StaticToken string
+
+func (t StaticToken) Token(ctx context.Context) (string, error) {
+	return string(t), nil
+}
+
+func (t StaticToken) Refresh(ctx context.Context) (string, error) {
+	return "", errors.New("token cannot be refreshed")
+}
+
+type Config struct {
+	// Base URL of the core, such as https://core.example.com
+	Endpoint string
+
+	// Defaults to DefaultAPIVersion
+	APIVersion string
+
+	// API level reported by the core, checked by CheckAPILevel; 0 if unknown
+	APILevel int
+
+	// Requests are sent without authentication if nil
+	TokenSource TokenSource
+
+	// Defaults to a client retrying transient failures with DefaultRetryPolicy, see NewRetryTransport
+	HTTPClient *http.Client
+}
+
+type Client struct {
+	endpoint    string
+	apiVersion  string
+	apiLevel    int
+	tokenSource TokenSource
+	httpClient  *http.Client
+}
+
+func NewClient(cfg Config) (*Client, error) {
+	endpoint := strings.TrimSuffix(cfg.Endpoint, "/")
+	if u, err := url.Parse(endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid core endpoint '%v'", cfg.Endpoint)
+	}
+
+	c := &Client{
+		endpoint:    endpoint,
+		apiVersion:  cfg.APIVersion,
+		apiLevel:    cfg.APILevel,
+		tokenSource: cfg.TokenSource,
+		httpClient:  cfg.HTTPClient,
+	}
+	if c.apiVersion == "" {
+		c.apiVersion = DefaultAPIVersion
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Transport: NewRetryTransport(http.DefaultTransport, DefaultRetryPolicy)}
+	}
+
+	return c, nil
+}
+
+func (c *Client) Endpoint() string {
+	return c.endpoint
+}
+
+func (c *Client) APILevel() int {
+	return c.apiLevel
+}
+
+// CheckAPILevel returns an APILevelError if the API level of the core is outside the interval; 0 means no bound
+func (c *Client) CheckAPILevel(min int, max int) error {
+	return CheckAPILevel(c.apiLevel, min, max)
+}
+
+// Builds the URL of a resource collection, or of a single resource when id is set
+func (c *Client) resourceURL(project string, resource ResourceType, id string, params url.Values) string {
+	u := c.endpoint + "/api/" + c.apiVersion
+	if resource != Projects && project != "" {
+		u += "/-/" + url.PathEscape(project)
+	}
+	u += "/" + string(resource)
+	if id != "" {
+		u += "/" + url.PathEscape(id)
+	}
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	return u
+}
+
+// APIURL resolves a path against the base of the API, /api/<version>, under /-/<project> when project is set;
+// paths already starting with /api/ are only resolved against the endpoint, regardless of project
+func (c *Client) APIURL(project string, path string, params url.Values) string {
+	path = "/" + strings.TrimPrefix(path, "/")
+	u := c.endpoint
+	if !strings.HasPrefix(path, "/api/") {
+		u += "/api/" + c.apiVersion
+		if project != "" {
+			u += "/-/" + url.PathEscape(project)
+		}
+	}
+	u += path
+	if len(params) > 0 {
+		if strings.Contains(u, "?") {
+			u += "&" + params.Encode()
+		} else {
+			u += "?" + params.Encode()
+		}
+	}
+
+	return u
+}
+
+// Do sends a request with a JSON body, if not nil, to a URL of the core, such as one built with APIURL,
+// and returns the body of the response
+func (c *Client) Do(ctx context.Context, method string, u string, body []byte) ([]byte, error) {
+	return c.do(ctx, method, u, body)
+}
+
+// Sends a request with a JSON body, if not nil, and decodes the JSON response into out, if not nil
+func (c *Client) doJSON(ctx context.Context, method string, u string, in interface{}, out interface{}) error {
+	var body []byte
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = b
+	}
+
+	resp, err := c.do(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(resp) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp, out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+
+	return nil
+}
+
+// Sends a request, refreshing the token and replaying the request once if it is rejected
+func (c *Client) do(ctx context.Context, method string, u string, body []byte) ([]byte, error) {
+	token := ""
+	if c.tokenSource != nil {
+		t, err := c.tokenSource.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		token = t
+	}
+
+	resp, respBody, err := c.send(ctx, method, u, body, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && c.tokenSource != nil && token != "" {
+		t, refreshErr := c.tokenSource.Refresh(ctx)
+		if refreshErr != nil {
+			// Both errors are kept, so that callers can tell an expired session from a failing token endpoint
+			return nil, fmt.Errorf("%w (token refresh failed: %w)", NewAPIError(resp, respBody), refreshErr)
+		}
+		resp, respBody, err = c.send(ctx, method, u, body, t)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, NewAPIError(resp, respBody)
+	}
+
+	return respBody, nil
+}
+
+func (c *Client) send(ctx context.Context, method string, u string, body []byte, token string) (*http.Response, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error performing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading response: %w", err)
+	}
+
+	return resp, respBody, nil
+}
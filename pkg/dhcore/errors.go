@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dhcore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Errors returned by the client, to be checked with errors.Is
+var (
+	ErrBadRequest          = errors.New("bad request")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrForbidden           = errors.New("forbidden")
+	ErrNotFound            = errors.New("not found")
+	ErrConflict            = errors.New("conflict")
+	ErrServer              = errors.New("server error")
+	ErrAPILevelUnsupported = errors.New("API level not supported")
+	ErrNotPaginated        = errors.New("response is not a page of a paginated collection")
+)
+
+// APIError is returned when the core responds with an error status
+type APIError struct {
+	StatusCode int
+	Status     string
+	Message    string
+
+	// Path of the request, as reported by the core or else as sent
+	Path string
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("core responded with: %v - %v", e.Status, e.Message)
+	}
+	return fmt.Sprintf("core responded with: %v", e.Status)
+}
+
+// Unwrap returns the error matching the status code, if any
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity:
+		return ErrBadRequest
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusConflict:
+		return ErrConflict
+	case e.StatusCode >= 500:
+		return ErrServer
+	}
+	return nil
+}
+
+// NewAPIError builds the error for a response with an error status, reading the message from its body when present
+func NewAPIError(resp *http.Response, body []byte) *APIError {
+	e := &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
+	if resp.Request != nil && resp.Request.URL != nil {
+		e.Path = resp.Request.URL.Path
+	}
+
+	// Error bodies of the core carry message and path, with error as a short description of the status
+	var bodyMap map[string]interface{}
+	if err := json.Unmarshal(body, &bodyMap); err == nil {
+		if message, ok := bodyMap["message"].(string); ok && message != "" {
+			e.Message = message
+		} else if description, ok := bodyMap["error"].(string); ok {
+			e.Message = description
+		}
+		if path, ok := bodyMap["path"].(string); ok && path != "" {
+			e.Path = path
+		}
+	}
+
+	return e
+}
+
+// APILevelError is returned when the API level of the core is outside the interval an operation supports
+type APILevelError struct {
+	// Level is 0 when the core did not report its API level
+	Level int
+	Min   int
+	Max   int
+}
+
+func (e *APILevelError) Error() string {
+	if e.Level == 0 {
+		return "unable to check compatibility, environment does not specify API level"
+	}
+
+	supportedInterval := ""
+	if e.Min != 0 {
+		supportedInterval += fmt.Sprintf("%v <= ", e.Min)
+	}
+	supportedInterval += "level"
+	if e.Max != 0 {
+		supportedInterval += fmt.Sprintf(" <= %v", e.Max)
+	}
+	return fmt.Sprintf("API level %v is not within the supported interval for this command: %v", e.Level, supportedInterval)
+}
+
+func (e *APILevelError) Unwrap() error {
+	return ErrAPILevelUnsupported
+}
+
+// CheckAPILevel returns an APILevelError if level is unknown or outside the interval; 0 means no bound
+func CheckAPILevel(level int, min int, max int) error {
+	if level == 0 || (min != 0 && level < min) || (max != 0 && level > max) {
+		return &APILevelError{Level: level, Min: min, Max: max}
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dhcore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// ResourceType is the name of a collection of the core API
+type ResourceType string
+
+const (
+	Projects  ResourceType = "projects"
+	Artifacts ResourceType = "artifacts"
+	DataItems ResourceType = "dataitems"
+	Functions ResourceType = "functions"
+	Models    ResourceType = "models"
+	Runs      ResourceType = "runs"
+	Workflows ResourceType = "workflows"
+)
+
+const defaultPageSize = 200
+
+// Resource is an entity as returned by the core
+type Resource map[string]interface{}
+
+func (r Resource) ID() string {
+	id, _ := r["id"].(string)
+	return id
+}
+
+func (r Resource) Name() string {
+	name, _ := r["name"].(string)
+	return name
+}
+
+type ListOptions struct {
+	Name  string
+	Kind  string
+	State string
+
+	// "all" or "latest"; by default, all versions are listed when filtering by name
+	Versions string
+
+	// Defaults to 200
+	PageSize int
+
+	// Field and direction, such as "updated,asc"
+	Sort string
+}
+
+// ResourceClient performs operations on a collection of resources, within a project for all but projects
+type ResourceClient struct {
+	client   *Client
+	project  string
+	resource ResourceType
+}
+
+// Resources returns a client for any collection of the core
+func (c *Client) Resources(project string, resource ResourceType) *ResourceClient {
+	return &ResourceClient{client: c, project: project, resource: resource}
+}
+
+func (c *Client) Projects() *ResourceClient {
+	return c.Resources("", Projects)
+}
+
+func (c *Client) Artifacts(project string) *ResourceClient {
+	return c.Resources(project, Artifacts)
+}
+
+func (c *Client) DataItems(project string) *ResourceClient {
+	return c.Resources(project, DataItems)
+}
+
+func (c *Client) Functions(project string) *ResourceClient {
+	return c.Resources(project, Functions)
+}
+
+func (c *Client) Models(project string) *ResourceClient {
+	return c.Resources(project, Models)
+}
+
+func (c *Client) Workflows(project string) *ResourceClient {
+	return c.Resources(project, Workflows)
+}
+
+func (r *ResourceClient) url(id string, params url.Values) string {
+	return r.client.resourceURL(r.project, r.resource, id, params)
+}
+
+func (r *ResourceClient) checkProject() error {
+	if r.resource != Projects && r.project == "" {
+		return fmt.Errorf("project is mandatory when working with %v", r.resource)
+	}
+	return nil
+}
+
+// A page of a paginated collection, as returned by the core
+type page[T any] struct {
+	Content  []T `json:"content"`
+	Pageable struct {
+		PageNumber int `json:"pageNumber"`
+	} `json:"pageable"`
+	TotalPages int `json:"totalPages"`
+}
+
+// Fetches the page at the URL and all the following ones, calling fn with the content of each, which is nil
+// if the response has none
+func forEachPage[T any](ctx context.Context, c *Client, u string, fn func([]T) error) error {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return fmt.Errorf("invalid URL '%v': %w", u, err)
+	}
+	params := parsed.Query()
+
+	for {
+		p := page[T]{}
+		if err := c.doJSON(ctx, "GET", parsed.String(), nil, &p); err != nil {
+			return err
+		}
+		if err := fn(p.Content); err != nil {
+			return err
+		}
+
+		if p.Pageable.PageNumber >= p.TotalPages-1 {
+			return nil
+		}
+		params.Set("page", strconv.Itoa(p.Pageable.PageNumber+1))
+		parsed.RawQuery = params.Encode()
+	}
+}
+
+// Fetches the page at the URL and all the following ones, returning their content
+func fetchAllPages[T any](ctx context.Context, c *Client, u string) ([]T, error) {
+	items := []T{}
+	err := forEachPage(ctx, c, u, func(content []T) error {
+		items = append(items, content...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// GetAllPages fetches a paginated collection at a URL of the core, such as one built with APIURL,
+// following its pages, and returns their content
+func (c *Client) GetAllPages(ctx context.Context, u string) ([]json.RawMessage, error) {
+	items := []json.RawMessage{}
+	err := forEachPage(ctx, c, u, func(content []json.RawMessage) error {
+		// Any path can be requested: responses without content are not pages, rather than empty collections
+		if content == nil {
+			return ErrNotPaginated
+		}
+		items = append(items, content...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// List returns the resources matching the options, fetching all pages
+func (r *ResourceClient) List(ctx context.Context, opts ListOptions) ([]Resource, error) {
+	if err := r.checkProject(); err != nil {
+		return nil, err
+	}
+
+	return fetchAllPages[Resource](ctx, r.client, r.listURL(opts))
+}
+
+// ListPages calls fn with the resources matching the options, page by page as they are fetched, so that
+// they can be processed without holding all of them in memory
+func (r *ResourceClient) ListPages(ctx context.Context, opts ListOptions, fn func([]Resource) error) error {
+	if err := r.checkProject(); err != nil {
+		return err
+	}
+
+	return forEachPage(ctx, r.client, r.listURL(opts), fn)
+}
+
+func (r *ResourceClient) listURL(opts ListOptions) string {
+	params := url.Values{}
+	for k, v := range map[string]string{"name": opts.Name, "kind": opts.Kind, "state": opts.State, "versions": opts.Versions, "sort": opts.Sort} {
+		if v != "" {
+			params.Set(k, v)
+		}
+	}
+	if opts.Name != "" && opts.Versions == "" {
+		params.Set("versions", "all")
+	}
+	size := opts.PageSize
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	params.Set("size", strconv.Itoa(size))
+
+	return r.url("", params)
+}
+
+// Get returns the resource with the given id
+func (r *ResourceClient) Get(ctx context.Context, id string) (Resource, error) {
+	raw, err := r.GetRaw(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return decodeResource(raw)
+}
+
+// GetRaw returns the resource with the given id as sent by the core, keeping the order of its fields
+func (r *ResourceClient) GetRaw(ctx context.Context, id string) (json.RawMessage, error) {
+	if err := r.checkProject(); err != nil {
+		return nil, err
+	}
+
+	return r.client.do(ctx, "GET", r.url(id, nil), nil)
+}
+
+// GetLatest returns the latest version of the resource with the given name
+func (r *ResourceClient) GetLatest(ctx context.Context, name string) (Resource, error) {
+	raw, err := r.GetLatestRaw(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return decodeResource(raw)
+}
+
+// GetLatestRaw returns the latest version of the resource with the given name as sent by the core
+func (r *ResourceClient) GetLatestRaw(ctx context.Context, name string) (json.RawMessage, error) {
+	if err := r.checkProject(); err != nil {
+		return nil, err
+	}
+
+	p := page[json.RawMessage]{}
+	params := url.Values{"name": {name}, "versions": {"latest"}}
+	if err := r.client.doJSON(ctx, "GET", r.url("", params), nil, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Content) == 0 {
+		return nil, fmt.Errorf("%v '%v': %w", r.resource, name, ErrNotFound)
+	}
+	return p.Content[0], nil
+}
+
+func decodeResource(raw json.RawMessage) (Resource, error) {
+	res := Resource{}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return res, nil
+}
+
+// Create creates a resource and returns it as stored by the core
+func (r *ResourceClient) Create(ctx context.Context, res Resource) (Resource, error) {
+	if err := r.checkProject(); err != nil {
+		return nil, err
+	}
+
+	created := Resource{}
+	if err := r.client.doJSON(ctx, "POST", r.url("", nil), res, &created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// Update replaces the resource with the given id and returns it as stored by the core
+func (r *ResourceClient) Update(ctx context.Context, id string, res Resource) (Resource, error) {
+	if err := r.checkProject(); err != nil {
+		return nil, err
+	}
+
+	updated := Resource{}
+	if err := r.client.doJSON(ctx, "PUT", r.url(id, nil), res, &updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Delete deletes the resource with the given id; with cascade, the resources belonging to it are deleted too
+func (r *ResourceClient) Delete(ctx context.Context, id string, cascade bool) error {
+	if err := r.checkProject(); err != nil {
+		return err
+	}
+
+	params := url.Values{"cascade": {strconv.FormatBool(cascade)}}
+	return r.client.doJSON(ctx, "DELETE", r.url(id, params), nil, nil)
+}
+
+// DeleteByName deletes all versions of the resources with the given name
+func (r *ResourceClient) DeleteByName(ctx context.Context, name string, cascade bool) error {
+	if err := r.checkProject(); err != nil {
+		return err
+	}
+	if r.resource == Projects {
+		return r.Delete(ctx, name, cascade)
+	}
+
+	params := url.Values{"name": {name}, "cascade": {strconv.FormatBool(cascade)}}
+	return r.client.doJSON(ctx, "DELETE", r.url("", params), nil, nil)
+}
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dhcore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy describes how requests failing for transient reasons are retried
+type RetryPolicy struct {
+	// Number of retries after the first attempt; 0 disables retries
+	MaxRetries int
+
+	// Total time, from the first attempt, after which no retry is started; 0 means no limit
+	Budget time.Duration
+
+	// Delay before the first retry, doubled at each following one up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// Called before waiting for a retry, if not nil
+	OnRetry func(req *http.Request, attempt int, delay time.Duration, reason string)
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	Budget:     30 * time.Second,
+	BaseDelay:  500 * time.Millisecond,
+	MaxDelay:   10 * time.Second,
+}
+
+// Methods whose requests can be sent again without side effects
+var idempotentMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete}
+
+var retryableStatuses = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+type retryTransport struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+}
+
+// NewRetryTransport wraps a transport to retry idempotent requests on network errors and on 429, 502, 503 and 504
+// responses, with exponential backoff and jitter, honouring Retry-After
+func NewRetryTransport(next http.RoundTripper, policy RetryPolicy) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &retryTransport{next: next, policy: policy}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.policy.MaxRetries <= 0 || !slices.Contains(idempotentMethods, req.Method) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return t.next.RoundTrip(req)
+	}
+
+	start := time.Now()
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := t.next.RoundTrip(req)
+
+		reason := ""
+		var retryAfter time.Duration
+		switch {
+		case err != nil:
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || req.Context().Err() != nil {
+				return nil, err
+			}
+			reason = err.Error()
+		case slices.Contains(retryableStatuses, resp.StatusCode):
+			reason = resp.Status
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		default:
+			return resp, nil
+		}
+
+		if attempt >= t.policy.MaxRetries {
+			return resp, err
+		}
+
+		delay := t.policy.backoff(attempt)
+		if retryAfter > 0 {
+			delay = retryAfter
+		}
+		if t.policy.Budget > 0 && time.Since(start)+delay > t.policy.Budget {
+			return resp, err
+		}
+
+		// Response is discarded: drain it so that the connection can be reused
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+			resp.Body.Close()
+		}
+
+		if t.policy.OnRetry != nil {
+			t.policy.OnRetry(req, attempt+1, delay, reason)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Exponential backoff with equal jitter: half of the delay is fixed, the other half random
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = DefaultRetryPolicy.BaseDelay
+	}
+	delay := base << attempt
+	if p.MaxDelay > 0 && (delay > p.MaxDelay || delay <= 0) {
+		delay = p.MaxDelay
+	}
+
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// Reads a Retry-After header, either in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func (p RetryPolicy) String() string {
+	return fmt.Sprintf("%v retries within %v", p.MaxRetries, p.Budget)
+}
@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dhcore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Operations that can be performed on a run
+var RunOperations = []string{"stop", "run", "resume", "delete", "build"}
+
+// RunClient adds the operations specific to runs to a ResourceClient
+type RunClient struct {
+	*ResourceClient
+}
+
+func (c *Client) Runs(project string) *RunClient {
+	return &RunClient{c.Resources(project, Runs)}
+}
+
+// Operate performs an operation on a run, one of RunOperations
+func (r *RunClient) Operate(ctx context.Context, id string, operation string) error {
+	if err := r.checkProject(); err != nil {
+		return err
+	}
+	op := strings.ToLower(operation)
+	if !slices.Contains(RunOperations, op) {
+		return fmt.Errorf("operation '%v' not supported, supported operations: %v", op, strings.Join(RunOperations, ", "))
+	}
+
+	return r.client.doJSON(ctx, "POST", r.url(id, nil)+"/"+op, nil, nil)
+}
+
+// Logs returns the logs of a run
+func (r *RunClient) Logs(ctx context.Context, id string) ([]Resource, error) {
+	if err := r.checkProject(); err != nil {
+		return nil, err
+	}
+
+	logs := []Resource{}
+	if err := r.client.doJSON(ctx, "GET", r.url(id, nil)+"/logs", nil, &logs); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
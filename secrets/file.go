@@ -0,0 +1,209 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"filippo.io/age"
+	"golang.org/x/term"
+)
+
+const (
+	secretsFileName  = ".dhcore.secrets"
+	passphraseEnvVar = "DHCLI_SECRETS_PASSPHRASE"
+)
+
+// Holds secrets in a file encrypted with a passphrase, for systems without a keyring. The file is read again
+// by every operation, as other invocations may change it; callers hold the ini lock while changing secrets,
+// so that Set and Delete apply to the current content of the file.
+type fileStore struct {
+	path       string
+	passphrase string
+
+	// Last content read from the file and its secrets, to decrypt it again only once it changes
+	data    []byte
+	secrets map[string]string
+}
+
+func newFileStore() *fileStore {
+	path, err := os.UserHomeDir()
+	if err != nil {
+		path = "."
+	}
+
+	return &fileStore{path: filepath.Join(path, secretsFileName)}
+}
+
+func (s *fileStore) Name() string {
+	return File
+}
+
+func (s *fileStore) Get(key string) (string, error) {
+	if err := s.load(); err != nil {
+		return "", err
+	}
+
+	value, ok := s.secrets[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (s *fileStore) Set(key string, value string) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	if current, ok := s.secrets[key]; ok && current == value {
+		return nil
+	}
+	s.secrets[key] = value
+	return s.save()
+}
+
+func (s *fileStore) Delete(key string) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	if _, ok := s.secrets[key]; !ok {
+		return ErrNotFound
+	}
+	delete(s.secrets, key)
+	return s.save()
+}
+
+// Reads the current content of the file; a missing file is an empty store
+func (s *fileStore) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		if s.passphrase == "" {
+			if s.passphrase, err = readPassphrase(true); err != nil {
+				return err
+			}
+		}
+		s.data = nil
+		s.secrets = map[string]string{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read secrets file: %w", err)
+	}
+	if s.secrets != nil && bytes.Equal(data, s.data) {
+		return nil
+	}
+
+	if s.passphrase == "" {
+		if s.passphrase, err = readPassphrase(false); err != nil {
+			return err
+		}
+	}
+	identity, err := age.NewScryptIdentity(s.passphrase)
+	if err != nil {
+		return err
+	}
+	r, err := age.Decrypt(bytes.NewReader(data), identity)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt secrets file: %w", err)
+	}
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt secrets file: %w", err)
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return fmt.Errorf("secrets file is corrupted: %w", err)
+	}
+	s.data = data
+	s.secrets = secrets
+	return nil
+}
+
+// Writes the secrets; on failure, they are read from the file again by the next operation
+func (s *fileStore) save() error {
+	if err := s.write(); err != nil {
+		s.secrets = nil
+		return err
+	}
+	return nil
+}
+
+func (s *fileStore) write() error {
+	plain, err := json.Marshal(s.secrets)
+	if err != nil {
+		return err
+	}
+
+	recipient, err := age.NewScryptRecipient(s.passphrase)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipient)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(plain); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.data = buf.Bytes()
+	return nil
+}
+
+// Reads the passphrase from the environment, or prompts for it when running in a terminal
+func readPassphrase(confirm bool) (string, error) {
+	if p := os.Getenv(passphraseEnvVar); p != "" {
+		return p, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("a passphrase is required to access the secrets file, set it with %v", passphraseEnvVar)
+	}
+
+	fmt.Fprint(os.Stderr, "Secrets file passphrase: ")
+	p, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("error in reading passphrase: %w", err)
+	}
+	if len(p) == 0 {
+		return "", errors.New("passphrase cannot be empty")
+	}
+
+	if confirm {
+		fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+		c, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("error in reading passphrase: %w", err)
+		}
+		if !bytes.Equal(p, c) {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+
+	return string(p), nil
+}
@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package secrets
+
+import (
+	"errors"
+
+	"github.com/zalando/go-keyring"
+)
+
+const keyringService = "dhcli"
+
+// Holds secrets in the OS keyring (Secret Service, macOS Keychain or Windows Credential Manager)
+type keyringStore struct{}
+
+func (s *keyringStore) Name() string {
+	return Keyring
+}
+
+func (s *keyringStore) Get(key string) (string, error) {
+	value, err := keyring.Get(keyringService, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	return value, err
+}
+
+func (s *keyringStore) Set(key string, value string) error {
+	return keyring.Set(keyringService, key, value)
+}
+
+func (s *keyringStore) Delete(key string) error {
+	err := keyring.Delete(keyringService, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// Prefix of ini values that point to a secret held by a Store
+	refPrefix = "secret:"
+
+	Plain   = "ini"
+	Keyring = "keyring"
+	File    = "file"
+)
+
+var ErrNotFound = errors.New("secret not found")
+
+// Store is a backend able to hold secret values outside the ini file
+type Store interface {
+	Name() string
+	Get(key string) (string, error)
+	Set(key string, value string) error
+	Delete(key string) error
+}
+
+var stores = map[string]Store{}
+
+// GetStore returns the store registered with the given name; plaintext storage has no store
+func GetStore(name string) (Store, error) {
+	if s, ok := stores[name]; ok {
+		return s, nil
+	}
+
+	var s Store
+	switch name {
+	case Keyring:
+		s = &keyringStore{}
+	case File:
+		s = newFileStore()
+	default:
+		return nil, fmt.Errorf("unknown secret store '%v', supported values are: %v, %v, %v", name, Plain, Keyring, File)
+	}
+
+	stores[name] = s
+	return s, nil
+}
+
+// IsReference reports whether an ini value is a reference to a stored secret
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, refPrefix)
+}
+
+// Reference builds the ini value pointing to a secret held by the store
+func Reference(store Store, key string) string {
+	return refPrefix + store.Name() + ":" + key
+}
+
+// Resolve returns the secret a reference points to
+func Resolve(ref string) (string, error) {
+	store, key, err := parseReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	return store.Get(key)
+}
+
+// Remove deletes the secret a reference points to; missing secrets are not an error
+func Remove(ref string) error {
+	store, key, err := parseReference(ref)
+	if err != nil {
+		return err
+	}
+
+	if err := store.Delete(key); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+func parseReference(ref string) (Store, string, error) {
+	name, key, ok := strings.Cut(strings.TrimPrefix(ref, refPrefix), ":")
+	if !IsReference(ref) || !ok || key == "" {
+		return nil, "", fmt.Errorf("invalid secret reference '%v'", ref)
+	}
+
+	store, err := GetStore(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return store, key, nil
+}
@@ -0,0 +1,352 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"dhcli/pkg/dhcore"
+)
+
+// Modes of the HTTP cassette, set with --record and --replay
+const (
+	CassetteOff = iota
+	CassetteRecord
+	CassetteReplay
+)
+
+var cassetteMode = CassetteOff
+var cassetteDir = ""
+
+// Loaded once, so that exchanges recorded for the same request are served in order across clients
+var replay *replayTransport
+
+// SetCassette makes the HTTP client save each exchange to files in dir, or serve responses from them
+// instead of the network
+func SetCassette(mode int, dir string) error {
+	switch mode {
+	case CassetteRecord:
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("failed to create record directory: %w", err)
+		}
+	case CassetteReplay:
+		t, err := loadReplayTransport(dir)
+		if err != nil {
+			return err
+		}
+		replay = t
+	}
+
+	cassetteMode = mode
+	cassetteDir = dir
+	httpClient = nil
+	return nil
+}
+
+// An exchange as saved to a cassette file, with credentials redacted
+type cassetteExchange struct {
+	Request  cassetteRequest  `json:"request"`
+	Response cassetteResponse `json:"response"`
+}
+
+type cassetteRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+type cassetteResponse struct {
+	StatusCode int         `json:"status_code"`
+	Status     string      `json:"status"`
+	Headers    http.Header `json:"headers,omitempty"`
+
+	// Small textual bodies are saved as they are; others, such as downloads, in a file of their own
+	Body     string `json:"body,omitempty"`
+	BodyFile string `json:"body_file,omitempty"`
+}
+
+// Textual bodies up to this size are saved in the exchange, redacted; larger ones are streamed to a file
+const maxInlineBody = 1 << 20
+
+// Builds the transport of the HTTP client on top of the one sending requests over the network. The recorder
+// sits above retries, so that only the final response of each request is saved; when replaying, recorded
+// responses replace the network and are not retried.
+func cassetteTransport(next http.RoundTripper, policy dhcore.RetryPolicy) http.RoundTripper {
+	switch cassetteMode {
+	case CassetteRecord:
+		return &recordingTransport{next: dhcore.NewRetryTransport(traceTransport(next), policy), dir: cassetteDir}
+	case CassetteReplay:
+		return traceTransport(replay)
+	}
+	return dhcore.NewRetryTransport(traceTransport(next), policy)
+}
+
+type recordingTransport struct {
+	next http.RoundTripper
+	dir  string
+}
+
+// Exchanges are numbered after those already in the directory, from previous recordings or clients
+var recordMutex sync.Mutex
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	head, err := io.ReadAll(io.LimitReader(resp.Body, maxInlineBody+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	inline := len(head) <= maxInlineBody && isTextual(contentType)
+
+	exchange := cassetteExchange{
+		Request: cassetteRequest{
+			Method:  req.Method,
+			URL:     redactURL(req.URL),
+			Headers: redactHeaders(req.Header),
+			Body:    redactBody(req.Header.Get("Content-Type"), reqBody),
+		},
+		Response: cassetteResponse{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Headers:    redactHeaders(resp.Header),
+		},
+	}
+	if len(reqBody) > 0 && !isTextual(req.Header.Get("Content-Type")) {
+		exchange.Request.Body = fmt.Sprintf("(%v bytes of %v)", len(reqBody), req.Header.Get("Content-Type"))
+	}
+	if inline {
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(head))
+		exchange.Response.Body = redactBody(contentType, head)
+	}
+
+	bodyFile, err := t.save(exchange, !inline)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to record exchange: %w", err)
+	}
+	if inline {
+		return resp, nil
+	}
+
+	// The body is written to its file as the caller reads it, rather than held in memory
+	f, err := os.OpenFile(bodyFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err == nil {
+		_, err = f.Write(head)
+	}
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to record exchange: %w", err)
+	}
+	resp.Body = &recordedBody{
+		Reader: io.MultiReader(bytes.NewReader(head), io.TeeReader(resp.Body, f)),
+		body:   resp.Body,
+		file:   f,
+	}
+
+	return resp, nil
+}
+
+// A response body copied to a file while it is read
+type recordedBody struct {
+	io.Reader
+	body io.Closer
+	file *os.File
+}
+
+func (b *recordedBody) Close() error {
+	b.file.Close()
+	return b.body.Close()
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// Saves the exchange and, if the body is kept in a file of its own, returns the path of that file
+func (t *recordingTransport) save(exchange cassetteExchange, withBodyFile bool) (string, error) {
+	recordMutex.Lock()
+	defer recordMutex.Unlock()
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		return "", err
+	}
+	count := 0
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".json") {
+			count++
+		}
+	}
+
+	u, _ := url.Parse(exchange.Request.URL)
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(u.Path, "-"), "-")
+	if len(slug) > 60 {
+		slug = slug[:60]
+	}
+	name := fmt.Sprintf("%04d-%v-%v", count+1, exchange.Request.Method, slug)
+	if withBodyFile {
+		exchange.Response.BodyFile = name + ".body"
+	}
+
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(exchange); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(t.dir, name+".json"), data.Bytes(), 0600); err != nil {
+		return "", err
+	}
+
+	if !withBodyFile {
+		return "", nil
+	}
+	return filepath.Join(t.dir, exchange.Response.BodyFile), nil
+}
+
+func redactHeaders(header http.Header) http.Header {
+	result := http.Header{}
+	for name, values := range header {
+		for _, value := range values {
+			result.Add(name, redactHeader(name, value))
+		}
+	}
+	return result
+}
+
+type replayTransport struct {
+	mutex     sync.Mutex
+	exchanges map[string][]cassetteExchange
+	served    map[string]int
+	dir       string
+}
+
+func loadReplayTransport(dir string) (*replayTransport, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay directory: %w", err)
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	t := &replayTransport{exchanges: map[string][]cassetteExchange{}, served: map[string]int{}, dir: dir}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %v: %w", name, err)
+		}
+		exchange := cassetteExchange{}
+		if err := json.Unmarshal(data, &exchange); err != nil {
+			return nil, fmt.Errorf("invalid exchange %v: %w", name, err)
+		}
+		u, err := url.Parse(exchange.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL in %v: %w", name, err)
+		}
+		key := replayKey(exchange.Request.Method, u)
+		t.exchanges[key] = append(t.exchanges[key], exchange)
+	}
+
+	return t, nil
+}
+
+// Requests match on method, path and query, regardless of the order of parameters; sensitive values
+// are redacted in recordings, so they are in requests too
+func replayKey(method string, u *url.URL) string {
+	values, _ := url.ParseQuery(redactQuery(u.RawQuery))
+	for _, v := range values {
+		sort.Strings(v)
+	}
+	return method + " " + u.Path + "?" + values.Encode()
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+
+	key := replayKey(req.Method, req.URL)
+
+	// Exchanges recorded for the same request are served in order, the last one again once exhausted
+	t.mutex.Lock()
+	exchanges := t.exchanges[key]
+	if len(exchanges) == 0 {
+		t.mutex.Unlock()
+		return nil, fmt.Errorf("no recorded response for %v %v in %v", req.Method, redactURL(req.URL), t.dir)
+	}
+	i := t.served[key]
+	if i < len(exchanges)-1 {
+		t.served[key] = i + 1
+	}
+	exchange := exchanges[i]
+	t.mutex.Unlock()
+
+	var body io.ReadCloser = io.NopCloser(strings.NewReader(exchange.Response.Body))
+	length := int64(len(exchange.Response.Body))
+	if exchange.Response.BodyFile != "" {
+		f, err := os.Open(filepath.Join(t.dir, exchange.Response.BodyFile))
+		if err != nil {
+			return nil, fmt.Errorf("invalid recorded body for %v %v: %w", req.Method, req.URL.Path, err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("invalid recorded body for %v %v: %w", req.Method, req.URL.Path, err)
+		}
+		body = f
+		length = info.Size()
+	}
+
+	header := exchange.Response.Headers.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	// Redaction may have changed the length of the body, and bodies not read in full are recorded in part
+	header.Set("Content-Length", strconv.FormatInt(length, 10))
+
+	return &http.Response{
+		StatusCode:    exchange.Response.StatusCode,
+		Status:        exchange.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          body,
+		ContentLength: length,
+		Request:       req,
+	}, nil
+}
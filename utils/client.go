@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"context"
+	"log"
+
+	"gopkg.in/ini.v1"
+
+	"dhcli/pkg/dhcore"
+)
+
+// Provides the tokens of an environment, refreshing and storing them as needed
+type sectionTokenSource struct {
+	cfg     *ini.File
+	section *ini.Section
+}
+
+func (s *sectionTokenSource) Token(ctx context.Context) (string, error) {
+	token := s.section.Key("access_token").Value()
+	if token != "" && s.section.Key("refresh_token").Value() != "" && TokenExpired(token) {
+		if err := RefreshAccessToken(s.cfg, s.section); err != nil {
+			log.Printf("WARNING: Failed to refresh expired access token: %v\n", err)
+		}
+	}
+
+	return s.section.Key("access_token").Value(), nil
+}
+
+func (s *sectionTokenSource) Refresh(ctx context.Context) (string, error) {
+	if s.section.Key("refresh_token").Value() == "" {
+		return "", ErrNotLoggedIn
+	}
+	if err := RefreshAccessToken(s.cfg, s.section); err != nil {
+		return "", err
+	}
+
+	return s.section.Key("access_token").Value(), nil
+}
+
+// NewCoreClient returns a client for the core of an environment, sharing its connection settings and tokens
+func NewCoreClient(cfg *ini.File, section *ini.Section) (*dhcore.Client, error) {
+	apiLevel, err := ApiLevel(section)
+	if err != nil {
+		return nil, err
+	}
+
+	config := dhcore.Config{
+		Endpoint:   section.Key(DhCoreEndpoint).Value(),
+		APIVersion: section.Key(ApiVersionKey).Value(),
+		APILevel:   apiLevel,
+		HTTPClient: HTTPClient(),
+	}
+	if !AuthDisabled(section) {
+		config.TokenSource = &sectionTokenSource{cfg: cfg, section: section}
+	}
+
+	return dhcore.NewClient(config)
+}
+
+// LoadCoreClient loads an environment, updating it if outdated, and returns a client for its core
+// after checking that its API level is within the given interval, unless both bounds are 0
+func LoadCoreClient(env string, min int, max int) (*dhcore.Client, *ini.Section, error) {
+	cfg, section, err := LoadIniConfig([]string{env})
+	if err != nil {
+		return nil, nil, err
+	}
+	CheckUpdateEnvironment(cfg, section)
+
+	client, err := NewCoreClient(cfg, section)
+	if err != nil {
+		return nil, nil, err
+	}
+	if min != 0 || max != 0 {
+		if err := client.CheckAPILevel(min, max); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return client, section, nil
+}
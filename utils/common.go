@@ -20,6 +20,14 @@ import (
 	"time"
 
 	"gopkg.in/ini.v1"
+
+	"dhcli/pkg/dhcore"
+)
+
+// Environment loaded by LoadIniConfig, whose tokens are refreshed when requests are rejected
+var (
+	activeCfg     *ini.File
+	activeSection *ini.Section
 )
 
 func getIniPath() string {
@@ -32,25 +40,44 @@ func getIniPath() string {
 	return iniPath
 }
 
-func LoadIni(createOnMissing bool) *ini.File {
-	cfg, err := ini.Load(getIniPath())
+func LoadIni(createOnMissing bool) (*ini.File, error) {
+	cfg, err := loadIniFile()
 	if err != nil {
 		if !createOnMissing {
-			log.Printf("Failed to read ini file: %v\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("failed to read ini file: %w", err)
 		}
-		return ini.Empty()
+		return ini.Empty(), nil
 	}
 
-	return cfg
+	return cfg, nil
 }
 
-func SaveIni(cfg *ini.File) {
-	err := cfg.SaveTo(getIniPath())
+func SaveIni(cfg *ini.File) error {
+	// Environment built from environment variables alone, or loaded while replaying recorded requests
+	if transientFiles[cfg] {
+		return nil
+	}
+
+	// Other invocations may be updating the file, or the secret store, at the same time
+	unlock, err := lockIni()
+	if err != nil {
+		return fmt.Errorf("failed to update ini file: %w", err)
+	}
+	defer unlock()
+
+	restoreOverlays := persistOverlays(cfg)
+	defer restoreOverlays()
+
+	restoreSecrets, err := persistSecrets(cfg)
 	if err != nil {
-		log.Printf("Failed to update ini file: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to update secret store: %w", err)
 	}
+	defer restoreSecrets()
+
+	if err := writeMerged(cfg); err != nil {
+		return fmt.Errorf("failed to update ini file: %w", err)
+	}
+	return nil
 }
 
 func ReflectValue(v interface{}) string {
@@ -82,77 +109,91 @@ func ReflectValue(v interface{}) string {
 	}
 }
 
-func BuildCoreUrl(section *ini.Section, project string, resource string, id string, params map[string]string) string {
-	base := section.Key(DhCoreEndpoint).String() + "/api/" + section.Key("dhcore_api_version").String()
-	endpoint := ""
-	paramsString := ""
-	if resource != "projects" && project != "" {
-		endpoint += "/-/" + project
-	}
-	endpoint += "/" + resource
-	if id != "" {
-		endpoint += "/" + id
-	}
-	if params != nil && len(params) > 0 {
-		paramsString = "?"
-		for key, val := range params {
-			if val != "" {
-				paramsString += key + "=" + val + "&"
-			}
-		}
-		paramsString = paramsString[:len(paramsString)-1]
-	}
-
-	return base + endpoint + paramsString
-}
-
-func PrepareRequest(method string, url string, data []byte, accessToken string) *http.Request {
+func PrepareRequest(method string, url string, data []byte, accessToken string) (*http.Request, error) {
 	var body io.Reader = nil
 	if data != nil {
 		body = bytes.NewReader(data)
 	}
 	req, err := http.NewRequest(method, url, body)
 	if err != nil {
-		log.Printf("Failed to initialize request: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to initialize request: %w", err)
 	}
 
 	if data != nil {
 		req.Header.Add("Content-type", "application/json")
 	}
 
-	if accessToken != "" {
+	if accessToken != "" && !AuthDisabled(activeSection) {
 		req.Header.Add("Authorization", "Bearer "+accessToken)
 	}
 
-	return req
+	return req, nil
 }
 
 func DoRequest(req *http.Request) ([]byte, error) {
-	client := &http.Client{}
+	refreshable := canRefresh(req)
+	if refreshable && TokenExpired(activeSection.Key("access_token").String()) {
+		if err := refreshRequestToken(req); err != nil {
+			log.Printf("WARNING: Failed to refresh expired access token: %v\n", err)
+		}
+	}
+
+	client := HTTPClient()
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Printf("Error performing request: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("error performing request: %w", err)
+	}
+
+	// Access token was rejected: refresh it and replay the request once
+	if resp.StatusCode == http.StatusUnauthorized && refreshable {
+		resp.Body.Close()
+		if err := refreshRequestToken(req); err != nil {
+			return nil, fmt.Errorf("failed to refresh access token: %w", err)
+		}
+		resp, err = client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("error performing request: %w", err)
+		}
 	}
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
 
 	if resp.StatusCode != 200 {
-		// Extract message from body (if present), to return a more meaningful error
-		msg := ""
-		var bodyMap map[string]interface{}
-		if err := json.Unmarshal(body, &bodyMap); err == nil {
-			if message, ok := bodyMap["message"]; ok && reflect.ValueOf(message).Kind() == reflect.String {
-				msg += " - " + message.(string)
-			}
-		}
+		return nil, dhcore.NewAPIError(resp, body)
+	}
+
+	return body, nil
+}
 
-		log.Printf("Core responded with: %v%v\n", resp.Status, msg)
-		os.Exit(1)
+// Reports whether the request is authenticated with the active environment's token, and that token can be refreshed
+func canRefresh(req *http.Request) bool {
+	if activeSection == nil || activeSection.Key("refresh_token").String() == "" {
+		return false
 	}
+	accessToken := activeSection.Key("access_token").String()
 
-	return body, err
+	return accessToken != "" && req.Header.Get("Authorization") == "Bearer "+accessToken
+}
+
+// Refreshes the active environment's token and updates the request to use it
+func refreshRequestToken(req *http.Request) error {
+	if err := RefreshAccessToken(activeCfg, activeSection); err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+activeSection.Key("access_token").String())
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		req.Body = body
+	}
+
+	return nil
 }
 
 func TranslateFormat(format string) string {
@@ -165,6 +206,15 @@ func TranslateFormat(format string) string {
 	return "short"
 }
 
+// KnownFormat reports whether TranslateFormat recognizes a format, rather than falling back to short
+func KnownFormat(format string) bool {
+	switch strings.ToLower(format) {
+	case "", "short", "json", "yaml", "yml":
+		return true
+	}
+	return false
+}
+
 func loadConfig() map[string]interface{} {
 	file, err := os.ReadFile("./" + configFile)
 	if err != nil {
@@ -182,41 +232,77 @@ func loadConfig() map[string]interface{} {
 	return config
 }
 
-func LoadIniConfig(args []string) (*ini.File, *ini.Section) {
-	cfg := LoadIni(false)
+func LoadIniConfig(args []string) (*ini.File, *ini.Section, error) {
+	cfg, loadErr := loadIniFile()
 
 	sectionName := ""
+	environmentSource = SourceFlag
 
-	if len(args) == 0 || args[0] == "" {
-		if cfg.HasSection("DEFAULT") {
-			defaultSection, err := cfg.GetSection("DEFAULT")
-			if err != nil {
-				log.Printf("Error while reading default environment: %v\n", err)
-				os.Exit(1)
-			}
-			if defaultSection.HasKey("current_environment") {
-				sectionName = defaultSection.Key("current_environment").String()
-			}
+	if len(args) > 0 && args[0] != "" {
+		sectionName = args[0]
+		if sectionName == passedEnvironment {
+			environmentSource = passedEnvironmentSource
+		}
+	} else if env := os.Getenv(EnvironmentEnvVar); env != "" {
+		sectionName = env
+		environmentSource = SourceEnv
+	} else if loadErr == nil && cfg.HasSection("DEFAULT") {
+		defaultSection := cfg.Section("DEFAULT")
+		if defaultSection.HasKey(CurrentEnvironment) {
+			sectionName = defaultSection.Key(CurrentEnvironment).String()
+			environmentSource = SourceIni
 		}
+	}
 
+	// Without a matching section, environment variables alone may be enough to describe the environment
+	if (loadErr != nil || sectionName == "" || !cfg.HasSection(sectionName)) && os.Getenv(EnvVarName(DhCoreEndpoint)) != "" {
 		if sectionName == "" {
-			log.Println("Error: environment was not passed and default environment is not specified in ini file.")
-			os.Exit(1)
+			sectionName = transientEnvName
+			environmentSource = SourceDefault
 		}
-	} else {
-		sectionName = args[0]
+		cfg = ini.Empty()
+		transientFiles[cfg] = true
+		section := cfg.Section(sectionName)
+		applyLayers(section)
+		if err := configureHTTPClient(cfg, section); err != nil {
+			return nil, nil, err
+		}
+		activeCfg, activeSection = cfg, section
+
+		return cfg, section, nil
+	}
+
+	if loadErr != nil {
+		return nil, nil, fmt.Errorf("failed to read ini file: %w", loadErr)
+	}
+
+	if sectionName == "" {
+		return nil, nil, errors.New("environment was not passed and default environment is not specified in ini file")
 	}
 
 	section, err := cfg.GetSection(sectionName)
 	if err != nil {
-		log.Printf("Failed to read section '%s': %v.\n", sectionName, err)
-		os.Exit(1)
+		return nil, nil, fmt.Errorf("failed to read section '%s': %w", sectionName, err)
+	}
+
+	ResolveSecrets(section)
+	applyLayers(section)
+	if err := configureHTTPClient(cfg, section); err != nil {
+		return nil, nil, err
 	}
+	activeCfg, activeSection = cfg, section
 
-	return cfg, section
+	return cfg, section, nil
 }
 
-func TranslateEndpoint(resource string) string {
+func configureHTTPClient(cfg *ini.File, section *ini.Section) error {
+	if err := ConfigureHTTPClient(cfg, section); err != nil {
+		return fmt.Errorf("invalid connection settings for environment '%v': %w", section.Name(), err)
+	}
+	return nil
+}
+
+func TranslateEndpoint(resource string) (string, error) {
 	config := loadConfig()
 
 	if config != nil {
@@ -225,14 +311,14 @@ func TranslateEndpoint(resource string) string {
 
 			for key, val := range endpointsMap {
 				if key == resource {
-					return key
+					return key, nil
 				}
 
 				if reflect.ValueOf(val).Kind() == reflect.String && val != "" {
 					aliases := strings.Split(val.(string), ",")
 					for _, alias := range aliases {
 						if strings.TrimSpace(alias) == resource {
-							return key
+							return key, nil
 						}
 					}
 				}
@@ -240,29 +326,37 @@ func TranslateEndpoint(resource string) string {
 		}
 	}
 
-	log.Printf("Resource '%v' is not supported or the configuration file is invalid. Check or edit supported resources in %v.\n", resource, configFile)
-	os.Exit(1)
+	return "", fmt.Errorf("resource '%v' is not supported or the configuration file is invalid, check or edit supported resources in %v", resource, configFile)
+}
+
+// WideColumns returns the columns of the wide output of a resource, set in the configuration file, or an empty string
+func WideColumns(resource string) string {
+	config := loadConfig()
+	if wide, ok := config["wide_columns"].(map[string]interface{}); ok {
+		if columns, ok := wide[resource].(string); ok {
+			return columns
+		}
+	}
 	return ""
 }
 
-func WaitForConfirmation(msg string) {
+// WaitForConfirmation asks the user to confirm an operation, returning false if they decline
+func WaitForConfirmation(msg string) (bool, error) {
+	buf := bufio.NewReader(os.Stdin)
 	for {
-		buf := bufio.NewReader(os.Stdin)
 		log.Printf(msg)
 		userInput, err := buf.ReadBytes('\n')
 		if err != nil {
-			log.Printf("Error in reading user input: %v\n", err)
-			os.Exit(1)
-		} else {
-			yn := strings.TrimSpace(string(userInput))
-			if strings.ToLower(yn) == "y" || yn == "" {
-				break
-			} else if strings.ToLower(yn) == "n" {
-				log.Println("Cancelling.")
-				os.Exit(0)
-			}
-			log.Println("Invalid input, must be y or n")
+			return false, fmt.Errorf("error in reading user input: %w", err)
 		}
+
+		yn := strings.TrimSpace(string(userInput))
+		if strings.ToLower(yn) == "y" || yn == "" {
+			return true, nil
+		} else if strings.ToLower(yn) == "n" {
+			return false, nil
+		}
+		log.Println("Invalid input, must be y or n")
 	}
 }
 
@@ -281,32 +375,27 @@ func PrintCommentForYaml(section *ini.Section, args ...string) {
 	}
 }
 
-func CheckApiLevel(section *ini.Section, min int, max int) {
+func CheckApiLevel(section *ini.Section, min int, max int) error {
+	apiLevel, err := ApiLevel(section)
+	if err != nil {
+		return err
+	}
+
+	return dhcore.CheckAPILevel(apiLevel, min, max)
+}
+
+// ApiLevel returns the API level of the environment, 0 if it is not specified
+func ApiLevel(section *ini.Section) (int, error) {
 	if !section.HasKey(ApiLevelKey) {
-		log.Println("ERROR: Unable to check compatibility, environment does not specify API level.")
-		os.Exit(1)
+		return 0, nil
 	}
 
 	apiLevelString := section.Key(ApiLevelKey).Value()
 	apiLevel, err := strconv.Atoi(apiLevelString)
 	if err != nil {
-		log.Printf("ERROR: Unable to check compatibility, as API level %v could not be read as integer.\n", apiLevelString)
-		os.Exit(1)
-	}
-
-	supportedInterval := ""
-	if min != 0 {
-		supportedInterval += fmt.Sprintf("%v <= ", min)
-	}
-	supportedInterval += "level"
-	if max != 0 {
-		supportedInterval += fmt.Sprintf(" <= %v", max)
-	}
-
-	if (min != 0 && apiLevel < min) || (max != 0 && apiLevel > max) {
-		log.Printf("ERROR: API level %v is not within the supported interval for this command: %v\n", apiLevel, supportedInterval)
-		os.Exit(1)
+		return 0, fmt.Errorf("unable to check compatibility, as API level %v could not be read as integer", apiLevelString)
 	}
+	return apiLevel, nil
 }
 
 func GetStringValue(m map[string]interface{}, key string) string {
@@ -320,7 +409,7 @@ func GetStringValue(m map[string]interface{}, key string) string {
 }
 
 func FetchConfig(configURL string) (map[string]interface{}, error) {
-	resp, err := http.Get(configURL)
+	resp, err := HTTPClient().Get(configURL)
 	if err != nil {
 		return nil, err
 	}
@@ -4,16 +4,28 @@
 
 package utils
 
+import "time"
+
 const (
-	IniName            = ".dhcore.ini"
-	CurrentEnvironment = "current_environment"
-	configFile         = "config.json"
-	ApiLevelKey        = "dhcore_api_level"
-	ClientIdKey        = "dhcore_client_id"
-	UpdatedEnvKey      = "updated_environment"
-	DhCoreEndpoint     = "dhcore_endpoint"
+	IniName             = ".dhcore.ini"
+	CurrentEnvironment  = "current_environment"
+	configFile          = "config.json"
+	ApiLevelKey         = "dhcore_api_level"
+	ClientIdKey         = "dhcore_client_id"
+	UpdatedEnvKey       = "updated_environment"
+	DhCoreEndpoint      = "dhcore_endpoint"
+	ExpiresAtKey        = "expires_at"
+	RefreshExpiresAtKey = "refresh_expires_at"
+	SecretStoreKey      = "secret_store"
+	UpdateIntervalKey   = "update_interval"
+	ApiVersionKey       = "dhcore_api_version"
+	AuthKey             = "auth"
+
+	// Value of auth for cores that accept requests without a bearer token
+	AuthNone = "none"
 
-	outdatedAfterHours = 1
+	// How long the configuration fetched from the core is considered current, unless set by update_interval
+	defaultUpdateInterval = time.Hour
 
 	// API level the current version of the CLI was developed for
 	MinApiLevel = 10
@@ -37,4 +49,4 @@ const (
 	RunLogsMax    = 0
 )
 
-var OpenIdFields = []string{"authorization_endpoint", "token_endpoint", "issuer", "scopes_supported", "access_token", "refresh_token"}
+var OpenIdFields = []string{"authorization_endpoint", "token_endpoint", "device_authorization_endpoint", "userinfo_endpoint", "revocation_endpoint", "issuer", "scopes_supported", "access_token", "refresh_token"}
@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+const ContextFileName = ".dhcli.yaml"
+
+// Context holds per-directory defaults, applied to the common flags not set on the command line
+type Context struct {
+	Environment string   `json:"environment,omitempty"`
+	Project     string   `json:"project,omitempty"`
+	Output      string   `json:"output,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+}
+
+// FindContextFile looks for a context file in the working directory and its parents
+func FindContextFile() (string, bool) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		path := filepath.Join(dir, ContextFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// LoadContext reads the nearest context file; without one, it returns an empty context to be created in the working directory
+func LoadContext() (*Context, string, error) {
+	path, found := FindContextFile()
+	if !found {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, "", err
+		}
+		return &Context{}, filepath.Join(wd, ContextFileName), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, path, fmt.Errorf("failed to read context file: %w", err)
+	}
+
+	ctx := &Context{}
+	if err := yaml.UnmarshalStrict(data, ctx); err != nil {
+		return nil, path, fmt.Errorf("invalid context file %v: %w", path, err)
+	}
+
+	return ctx, path, nil
+}
+
+// SaveContext writes the context file, removing it when the context is empty
+func SaveContext(ctx *Context, path string) error {
+	if ctx.Environment == "" && ctx.Project == "" && ctx.Output == "" && len(ctx.Labels) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove context file: %w", err)
+		}
+		return nil
+	}
+
+	data, err := yaml.Marshal(ctx)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write context file: %w", err)
+	}
+
+	return nil
+}
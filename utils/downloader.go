@@ -3,6 +3,7 @@ package utils
 import (
 	"context"
 	s3client "dhcli/configs"
+	"dhcli/pkg/dhcore"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"io"
@@ -14,7 +15,7 @@ import (
 
 // DownloadHTTPFile function for get a file from http or https
 func DownloadHTTPFile(url string, destination string) error {
-	resp, err := http.Get(url)
+	resp, err := StreamingHTTPClient().Get(url)
 	if err != nil {
 		return err
 	}
@@ -25,6 +26,11 @@ func DownloadHTTPFile(url string, destination string) error {
 		}
 	}(resp.Body)
 
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("download failed: %w", dhcore.NewAPIError(resp, body))
+	}
+
 	out, err := os.Create(destination)
 	if err != nil {
 		return err
@@ -5,53 +5,121 @@
 package utils
 
 import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
 	"time"
 
 	"gopkg.in/ini.v1"
 )
 
+// Values of update_interval that disable the automatic update
+var disabledIntervals = []string{"0", "never", "off", "false"}
+
+// KeyChange describes a key whose value differs after an update; Old is empty for added keys
+type KeyChange struct {
+	Key string
+	Old string
+	New string
+}
+
+// CheckUpdateEnvironment updates the environment from the core configuration when it is older than its update interval
 func CheckUpdateEnvironment(cfg *ini.File, section *ini.Section) {
-	if section.HasKey(UpdatedEnvKey) {
-		updated, err := time.Parse(time.RFC3339, section.Key(UpdatedEnvKey).Value())
-		if err != nil || updated.Add(outdatedAfterHours*time.Hour).Before(time.Now()) {
-			updateEnvironment(cfg, section)
-		}
+	interval, enabled := updateInterval(cfg, section)
+	if !enabled || !section.HasKey(UpdatedEnvKey) {
+		return
+	}
+
+	updated, err := time.Parse(time.RFC3339, section.Key(UpdatedEnvKey).Value())
+	if err == nil && updated.Add(interval).After(time.Now()) {
+		return
+	}
+
+	if _, err := UpdateEnvironment(cfg, section); err != nil {
+		log.Printf("WARNING: Unable to update environment '%v': %v\n", section.Name(), err)
 	}
 }
 
-func updateEnvironment(cfg *ini.File, section *ini.Section) {
+// Returns the update interval of the environment, which may be set for all environments in the DEFAULT section
+func updateInterval(cfg *ini.File, section *ini.Section) (time.Duration, bool) {
+	value := ""
+	if section.HasKey(UpdateIntervalKey) {
+		value = section.Key(UpdateIntervalKey).Value()
+	} else if defaultSection := cfg.Section("DEFAULT"); defaultSection.HasKey(UpdateIntervalKey) {
+		value = defaultSection.Key(UpdateIntervalKey).Value()
+	}
+
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return defaultUpdateInterval, true
+	}
+	if slices.Contains(disabledIntervals, value) {
+		return 0, false
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < 0 {
+		log.Printf("WARNING: Invalid %v '%v', using %v.\n", UpdateIntervalKey, value, defaultUpdateInterval)
+		return defaultUpdateInterval, true
+	}
+	return interval, interval > 0
+}
+
+// UpdateEnvironment fetches the core configuration, stores it into the environment and returns the keys that changed
+func UpdateEnvironment(cfg *ini.File, section *ini.Section) ([]KeyChange, error) {
 	baseEndpoint := section.Key(DhCoreEndpoint).Value()
 	if baseEndpoint == "" {
-		return
+		return nil, fmt.Errorf("environment does not specify %v", DhCoreEndpoint)
 	}
+	baseEndpoint = strings.TrimSuffix(baseEndpoint, "/")
 
-	// Configuration
 	config, err := FetchConfig(baseEndpoint + "/.well-known/configuration")
 	if err != nil {
-		return
+		return nil, fmt.Errorf("fetching configuration failed: %w", err)
+	}
+	openIdConfig := map[string]interface{}{}
+	if !AuthDisabled(section) {
+		openIdConfig, err = FetchConfig(baseEndpoint + "/.well-known/openid-configuration")
+		if err != nil {
+			return nil, fmt.Errorf("fetching OpenID configuration failed: %w", err)
+		}
 	}
+
+	values := map[string]interface{}{}
 	for k, v := range config {
 		newKey := k
 		if newKey == ClientIdKey {
 			newKey = "client_id"
 		}
-		UpdateKey(section, newKey, v)
-	}
-
-	// OpenID Configuration
-	openIdConfig, err := FetchConfig(baseEndpoint + "/.well-known/openid-configuration")
-	if err != nil {
-		return
+		values[newKey] = v
 	}
 	for _, k := range OpenIdFields {
 		if v, ok := openIdConfig[k]; ok && v != "" {
-			UpdateKey(section, k, v)
+			values[k] = v
+		}
+	}
+
+	changes := []KeyChange{}
+	for k, v := range values {
+		old := ""
+		if section.HasKey(k) {
+			old = section.Key(k).Value()
+		}
+		if value := ReflectValue(v); value != old || !section.HasKey(k) {
+			changes = append(changes, KeyChange{Key: k, Old: old, New: value})
 		}
+		UpdateKey(section, k, v)
 	}
+	slices.SortFunc(changes, func(a, b KeyChange) int { return strings.Compare(a.Key, b.Key) })
 
 	// Update timestamp
-	section.Key(UpdatedEnvKey).SetValue(time.Now().Format(time.RFC3339))
-	SaveIni(cfg)
+	UpdateKey(section, UpdatedEnvKey, time.Now().Format(time.RFC3339))
+	if err := SaveIni(cfg); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
 }
 
 func UpdateKey(section *ini.Section, k string, v interface{}) {
@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/ini.v1"
+)
+
+// Keys of each section, as they were in the ini file when it was last read or written by this process
+type iniState map[string]map[string]string
+
+var (
+	// State each loaded file started from, to only write back what changed since
+	loadedStates = map[*ini.File]iniState{}
+
+	// Lock on the ini file, held by this process when lockDepth is positive
+	lockHandle *os.File
+	lockDepth  int
+)
+
+// Reads the ini file, remembering its state to merge changes into it when saved
+func loadIniFile() (*ini.File, error) {
+	cfg, err := ini.Load(getIniPath())
+	if err != nil {
+		return nil, err
+	}
+	loadedStates[cfg] = stateOf(cfg)
+
+	// Replayed responses carry redacted tokens, which must not replace the ones of the user
+	if cassetteMode == CassetteReplay {
+		transientFiles[cfg] = true
+	}
+
+	return cfg, nil
+}
+
+func stateOf(cfg *ini.File) iniState {
+	state := iniState{}
+	for _, section := range cfg.Sections() {
+		keys := map[string]string{}
+		for _, key := range section.Keys() {
+			keys[key.Name()] = key.Value()
+		}
+		state[section.Name()] = keys
+	}
+
+	return state
+}
+
+// Acquires the lock on the ini file, which other processes wait for; the lock is reentrant within the process
+func lockIni() (func(), error) {
+	if lockDepth == 0 {
+		f, err := os.OpenFile(getIniPath()+".lock", os.O_CREATE|os.O_RDWR, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open lock file: %w", err)
+		}
+		if err := lockFile(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock ini file: %w", err)
+		}
+		lockHandle = f
+	}
+	lockDepth++
+
+	return func() {
+		lockDepth--
+		if lockDepth == 0 {
+			unlockFile(lockHandle)
+			lockHandle.Close()
+			lockHandle = nil
+		}
+	}, nil
+}
+
+// Re-reads the ini file under lock, applies the changes made to cfg since it was loaded and replaces the file atomically
+func writeMerged(cfg *ini.File) error {
+	unlock, err := lockIni()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := ini.Load(getIniPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		current = ini.Empty()
+	} else if err != nil {
+		return err
+	}
+
+	base := loadedStates[cfg]
+	changed := stateOf(cfg)
+	for name := range base {
+		if _, ok := changed[name]; !ok {
+			current.DeleteSection(name)
+		}
+	}
+	for name, keys := range changed {
+		// Removed by another process in the meantime
+		if _, ok := base[name]; ok && !current.HasSection(name) {
+			continue
+		}
+
+		section := current.Section(name)
+		for k, v := range keys {
+			if old, ok := base[name][k]; !ok || old != v {
+				section.Key(k).SetValue(v)
+			}
+		}
+		for k := range base[name] {
+			if _, ok := keys[k]; !ok {
+				section.DeleteKey(k)
+			}
+		}
+	}
+
+	if err := writeAtomic(current); err != nil {
+		return err
+	}
+	loadedStates[cfg] = changed
+
+	return nil
+}
+
+// Writes the file to a temporary file next to it, then renames it, so that readers never see a partial file
+func writeAtomic(cfg *ini.File) error {
+	path := getIniPath()
+	mode := fs.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := cfg.WriteTo(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"os"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+// Layers a configuration value can come from, from lowest to highest priority
+const (
+	SourceDefault = "default"
+	SourceIni     = "ini"
+	SourceContext = "context"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+const (
+	envVarPrefix       = "DHCORE_"
+	EnvironmentEnvVar  = "DHCORE_ENV"
+	ProjectEnvVar      = "DHCORE_PROJECT"
+	ClientIdEnvVar     = "DHCORE_CLIENT_ID"
+	ClientSecretEnvVar = "DHCORE_CLIENT_SECRET"
+
+	// Name of the environment built from environment variables alone, when none is specified
+	transientEnvName = "dhcore"
+)
+
+// Built-in values for keys that neither the ini file nor the environment variables define
+var defaultValues = map[string]string{
+	ApiVersionKey: "v1",
+}
+
+// Keys that environment variables may override: connection settings and the access token, never the keys
+// login and token refreshes rely on, such as client_id, whose variables have a meaning of their own.
+// Keys starting with dhcore_ or aws_ keep their name as variables (DHCORE_ENDPOINT is dhcore_endpoint).
+var overridableKeys = append([]string{
+	DhCoreEndpoint, "dhcore_name", "dhcore_version", ApiVersionKey, ApiLevelKey, "access_token",
+	"aws_access_key_id", "aws_secret_access_key", "aws_session_token", "aws_region", "aws_endpoint_url",
+}, TransportKeys...)
+
+type overlay struct {
+	section  *ini.Section
+	source   string
+	value    string
+	iniValue string
+	inIni    bool
+}
+
+var (
+	// Keys whose value comes from a layer other than the ini file, which must not be written to it
+	overlays = map[*ini.Key]*overlay{}
+
+	// Files built from environment variables alone, or loaded while replaying, which are never saved
+	transientFiles = map[*ini.File]bool{}
+
+	environmentSource = ""
+
+	// Environment name passed to LoadIniConfig from a layer other than the command line, see SetEnvironmentSource
+	passedEnvironment       = ""
+	passedEnvironmentSource = ""
+)
+
+// EnvVarName returns the environment variable that overrides an ini key
+func EnvVarName(key string) string {
+	name := strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+	if strings.HasPrefix(key, "dhcore_") || strings.HasPrefix(key, "aws_") {
+		return name
+	}
+	return envVarPrefix + name
+}
+
+// Returns the ini key an environment variable overrides, or an empty string
+func envVarKey(name string) string {
+	for _, key := range overridableKeys {
+		if EnvVarName(key) == name {
+			return key
+		}
+	}
+	return ""
+}
+
+// Applies built-in defaults and environment variables on top of the values read from the ini file
+func applyLayers(section *ini.Section) {
+	for k, v := range defaultValues {
+		if !section.HasKey(k) {
+			key := section.Key(k)
+			key.SetValue(v)
+			overlays[key] = &overlay{section: section, source: SourceDefault, value: v}
+		}
+	}
+
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		k := envVarKey(name)
+		if k == "" || value == "" {
+			continue
+		}
+
+		o := &overlay{section: section, source: SourceEnv, value: value}
+		if section.HasKey(k) {
+			key := section.Key(k)
+			if previous, ok := overlays[key]; ok {
+				o.iniValue, o.inIni = previous.iniValue, previous.inIni
+			} else {
+				o.iniValue, o.inIni = key.Value(), true
+			}
+		}
+
+		key := section.Key(k)
+		key.SetValue(value)
+		overlays[key] = o
+	}
+}
+
+// KeySource returns the layer the current value of a key comes from
+func KeySource(key *ini.Key) string {
+	if o, ok := overlays[key]; ok && o.value == key.Value() {
+		return o.source
+	}
+	return SourceIni
+}
+
+// EnvironmentSource returns the layer the environment selected by LoadIniConfig comes from
+func EnvironmentSource() string {
+	return environmentSource
+}
+
+// SetEnvironmentSource records that an environment name about to be passed to LoadIniConfig comes from a layer
+// other than the command line, such as the context file
+func SetEnvironmentSource(name string, source string) {
+	passedEnvironment, passedEnvironmentSource = name, source
+}
+
+// Restores the ini values of keys overridden by other layers until the returned function is called;
+// keys changed after loading are written as they are
+func persistOverlays(cfg *ini.File) func() {
+	var restore []func()
+
+	for key, o := range overlays {
+		if section, err := cfg.GetSection(o.section.Name()); err != nil || section != o.section || key.Value() != o.value {
+			continue
+		}
+
+		if o.inIni {
+			key.SetValue(o.iniValue)
+			restore = append(restore, func() { key.SetValue(o.value) })
+			continue
+		}
+
+		name := key.Name()
+		o.section.DeleteKey(name)
+		delete(overlays, key)
+		restore = append(restore, func() {
+			newKey := o.section.Key(name)
+			newKey.SetValue(o.value)
+			overlays[newKey] = o
+		})
+	}
+
+	return func() {
+		for _, r := range restore {
+			r()
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"fmt"
+	"log"
+	"slices"
+
+	"gopkg.in/ini.v1"
+
+	"dhcli/secrets"
+)
+
+// Keys whose values are held by the configured secret store, with only a reference kept in the ini file
+var SecretKeys = []string{"access_token", "refresh_token", "aws_secret_access_key", "aws_session_token"}
+
+type resolvedSecret struct {
+	ref   string
+	value string
+}
+
+// Secrets read through their references, to write the same reference back when they do not change
+var resolvedSecrets = map[*ini.Key]resolvedSecret{}
+
+// ResolveSecrets replaces the secret references of a section with the values they point to
+func ResolveSecrets(section *ini.Section) {
+	for _, key := range section.Keys() {
+		ref := key.Value()
+		if !secrets.IsReference(ref) {
+			continue
+		}
+
+		value, err := secrets.Resolve(ref)
+		if err != nil {
+			log.Printf("WARNING: Unable to read '%v' of environment '%v' from secret store: %v\n", key.Name(), section.Name(), err)
+			value = ""
+		}
+		key.SetValue(value)
+		resolvedSecrets[key] = resolvedSecret{ref: ref, value: value}
+	}
+}
+
+// DeleteSecrets removes the stored secrets a section refers to
+func DeleteSecrets(section *ini.Section) {
+	for _, key := range section.Keys() {
+		deleteSecret(section, key)
+	}
+}
+
+// DeleteKey removes a key from a section, along with the stored secret it refers to
+func DeleteKey(section *ini.Section, name string) {
+	if !section.HasKey(name) {
+		return
+	}
+
+	deleteSecret(section, section.Key(name))
+	section.DeleteKey(name)
+}
+
+func deleteSecret(section *ini.Section, key *ini.Key) {
+	// Changes made while replaying are never saved, so the secrets they refer to are kept
+	if cassetteMode == CassetteReplay {
+		return
+	}
+
+	ref := key.Value()
+	if r, ok := resolvedSecrets[key]; ok {
+		ref = r.ref
+		delete(resolvedSecrets, key)
+	}
+	if !secrets.IsReference(ref) {
+		return
+	}
+
+	// Other invocations may be updating the secret store at the same time
+	unlock, err := lockIni()
+	if err != nil {
+		log.Printf("WARNING: Unable to remove '%v' of environment '%v' from secret store: %v\n", key.Name(), section.Name(), err)
+		return
+	}
+	defer unlock()
+
+	if err := secrets.Remove(ref); err != nil {
+		log.Printf("WARNING: Unable to remove '%v' of environment '%v' from secret store: %v\n", key.Name(), section.Name(), err)
+	}
+}
+
+// Moves secret values to the configured store, replacing them with references until the returned function is called
+func persistSecrets(cfg *ini.File) (func(), error) {
+	var store secrets.Store
+	if defaultSection := cfg.Section("DEFAULT"); defaultSection.HasKey(SecretStoreKey) {
+		if name := defaultSection.Key(SecretStoreKey).Value(); name != "" && name != secrets.Plain {
+			s, err := secrets.GetStore(name)
+			if err != nil {
+				return nil, err
+			}
+			store = s
+		}
+	}
+
+	values := map[*ini.Key]string{}
+	restore := func() {
+		for key, value := range values {
+			key.SetValue(value)
+		}
+	}
+
+	for _, section := range cfg.Sections() {
+		for _, key := range section.Keys() {
+			value := key.Value()
+			if !slices.Contains(SecretKeys, key.Name()) || secrets.IsReference(value) {
+				continue
+			}
+
+			r, resolved := resolvedSecrets[key]
+			ref := ""
+			switch {
+			case resolved && r.value == value && store != nil:
+				ref = r.ref
+			case value != "" && store != nil:
+				ref = secrets.Reference(store, section.Name()+"/"+key.Name())
+				if err := store.Set(section.Name()+"/"+key.Name(), value); err != nil {
+					restore()
+					return nil, fmt.Errorf("failed to store '%v' of environment '%v' in %v: %w", key.Name(), section.Name(), store.Name(), err)
+				}
+			}
+
+			// Value is no longer held by the store it was read from
+			if resolved && r.ref != ref {
+				if err := secrets.Remove(r.ref); err != nil {
+					log.Printf("WARNING: Unable to remove previous '%v' of environment '%v' from secret store: %v\n", key.Name(), section.Name(), err)
+				}
+				delete(resolvedSecrets, key)
+			}
+
+			if ref != "" {
+				resolvedSecrets[key] = resolvedSecret{ref: ref, value: value}
+				values[key] = value
+				key.SetValue(ref)
+			}
+		}
+	}
+
+	return restore, nil
+}
+
+// MaskSecret hides the value of secret keys, keeping a short prefix to tell values apart
+func MaskSecret(key string, value string) string {
+	if !slices.Contains(SecretKeys, key) || value == "" || secrets.IsReference(value) {
+		return value
+	}
+	if len(value) <= 8 {
+		return "****"
+	}
+	return value[:4] + "****"
+}
@@ -0,0 +1,246 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+
+	"dhcli/secrets"
+)
+
+// ErrNotLoggedIn is returned when an environment has no credentials to obtain an access token with
+var ErrNotLoggedIn = errors.New("not logged in, run 'dhcli login' first")
+
+// TokenError is returned when the token endpoint rejects a request
+type TokenError struct {
+	StatusCode int
+	Status     string
+	Body       string
+
+	// Reported instead of status and body, when the error the endpoint returned is known
+	Message string
+}
+
+func (e *TokenError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("token server error: %s\nBody: %s", e.Status, e.Body)
+}
+
+// Keys written by a login, removed when the session ends
+var TokenKeys = []string{"access_token", "refresh_token", "expires_in", "refresh_expires_in", ExpiresAtKey, RefreshExpiresAtKey, "scope", "session_state", "not-before-policy"}
+
+// Tokens are considered expired slightly before their actual expiration, to account for clock skew
+const tokenExpiryLeeway = 30 * time.Second
+
+// StoreTokens writes the keys of a token endpoint response into the environment section and saves the ini file
+func StoreTokens(cfg *ini.File, section *ini.Section, tkn []byte) error {
+	var m map[string]interface{}
+	if err := json.Unmarshal(tkn, &m); err != nil {
+		return fmt.Errorf("invalid token response: %w", err)
+	}
+
+	return StoreTokenMap(cfg, section, m)
+}
+
+// StoreTokenMap writes the keys of a decoded token response, along with the absolute expiration times
+func StoreTokenMap(cfg *ini.File, section *ini.Section, m map[string]interface{}) error {
+	if _, ok := m["access_token"]; !ok {
+		return errors.New("token response does not contain an access token")
+	}
+
+	for k, v := range m {
+		if !slices.Contains([]string{"client_id", "token_type", "id_token"}, k) {
+			UpdateKey(section, k, fmt.Sprint(v))
+		}
+	}
+	UpdateKey(section, "access_token", fmt.Sprint(m["access_token"]))
+	if rt, ok := m["refresh_token"]; ok {
+		UpdateKey(section, "refresh_token", fmt.Sprint(rt))
+	}
+
+	setExpiry(section, ExpiresAtKey, m["expires_in"])
+	if _, ok := m["refresh_token"]; ok {
+		setExpiry(section, RefreshExpiresAtKey, m["refresh_expires_in"])
+	}
+
+	return SaveIni(cfg)
+}
+
+// Converts a relative expires_in value into an absolute timestamp, removing the key if it is missing
+func setExpiry(section *ini.Section, key string, expiresIn interface{}) {
+	seconds, err := strconv.ParseFloat(fmt.Sprint(expiresIn), 64)
+	if expiresIn == nil || err != nil || seconds <= 0 {
+		section.DeleteKey(key)
+		return
+	}
+
+	UpdateKey(section, key, time.Now().Add(time.Duration(seconds)*time.Second).Format(time.RFC3339))
+}
+
+// RefreshAccessToken runs the refresh token grant for the environment and stores the new tokens
+func RefreshAccessToken(cfg *ini.File, section *ini.Section) error {
+	// Only one invocation at a time refreshes, the others use the tokens it stores
+	unlock, err := lockIni()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if adoptStoredTokens(cfg, section) && !TokenExpired(section.Key("access_token").Value()) {
+		return nil
+	}
+
+	refreshToken := section.Key("refresh_token").Value()
+	if refreshToken == "" {
+		return ErrNotLoggedIn
+	}
+	tokenEndpoint := section.Key("token_endpoint").Value()
+	if tokenEndpoint == "" {
+		return errors.New("token endpoint is not configured for this environment")
+	}
+
+	data := url.Values{}
+	data.Set("grant_type", "refresh_token")
+	data.Set("client_id", section.Key("client_id").Value())
+	data.Set("refresh_token", refreshToken)
+
+	resp, err := HTTPClient().Post(tokenEndpoint, "application/x-www-form-urlencoded", strings.NewReader(data.Encode()))
+	if err != nil {
+		return fmt.Errorf("error refreshing token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return &TokenError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
+	}
+
+	return StoreTokens(cfg, section, body)
+}
+
+// Replaces the tokens of the section with the ones in the ini file, if another invocation stored new ones since it was loaded
+func adoptStoredTokens(cfg *ini.File, section *ini.Section) bool {
+	if transientFiles[cfg] {
+		return false
+	}
+	stored, err := ini.Load(getIniPath())
+	if err != nil || !stored.HasSection(section.Name()) {
+		return false
+	}
+	storedSection := stored.Section(section.Name())
+
+	value := func(k string) string {
+		if !storedSection.HasKey(k) {
+			return ""
+		}
+		v := storedSection.Key(k).Value()
+		if secrets.IsReference(v) {
+			resolved, err := secrets.Resolve(v)
+			if err != nil {
+				return ""
+			}
+			return resolved
+		}
+		return v
+	}
+
+	refreshToken := value("refresh_token")
+	if refreshToken == "" || refreshToken == section.Key("refresh_token").Value() {
+		return false
+	}
+
+	for _, k := range TokenKeys {
+		if storedSection.HasKey(k) {
+			UpdateKey(section, k, value(k))
+		} else {
+			section.DeleteKey(k)
+		}
+	}
+
+	return true
+}
+
+// ClearTokens removes the tokens of a section, along with the stored secrets they refer to
+func ClearTokens(section *ini.Section) {
+	for _, k := range TokenKeys {
+		DeleteKey(section, k)
+	}
+}
+
+// AuthDisabled reports whether the environment was registered for a core that does not require authentication
+func AuthDisabled(section *ini.Section) bool {
+	return section != nil && section.HasKey(AuthKey) && section.Key(AuthKey).Value() == AuthNone
+}
+
+// ParseJWTClaims decodes the payload of a JWT, without verifying its signature
+func ParseJWTClaims(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token is not a JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT payload: %w", err)
+	}
+
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("invalid JWT claims: %w", err)
+	}
+
+	return claims, nil
+}
+
+// TokenExpiry returns the expiration time of a JWT; ok is false if the token is opaque or has no exp claim
+func TokenExpiry(token string) (expiry time.Time, ok bool) {
+	claims, err := ParseJWTClaims(token)
+	if err != nil {
+		return time.Time{}, false
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(exp), 0), true
+}
+
+// AccessTokenExpiry returns the expiration of the environment's access token, as stored at login or from its claims
+func AccessTokenExpiry(section *ini.Section) (time.Time, bool) {
+	if section.HasKey(ExpiresAtKey) {
+		if expiry, err := time.Parse(time.RFC3339, section.Key(ExpiresAtKey).Value()); err == nil {
+			return expiry, true
+		}
+	}
+	if section.HasKey("access_token") {
+		return TokenExpiry(section.Key("access_token").Value())
+	}
+	return time.Time{}, false
+}
+
+// TokenExpired reports whether the token is a JWT whose expiration is already in the past
+func TokenExpired(token string) bool {
+	expiry, ok := TokenExpiry(token)
+	return ok && time.Now().Add(tokenExpiryLeeway).After(expiry)
+}
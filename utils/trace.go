@@ -0,0 +1,246 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Levels of HTTP tracing, set with --verbose and --debug
+const (
+	TraceOff = iota
+	TraceVerbose
+	TraceDebug
+)
+
+// Bodies larger than this are not logged, nor are they read ahead of the caller
+const maxTracedBody = 64 << 10
+
+const redacted = "***"
+
+var traceLevel = TraceOff
+var printCurl = false
+
+// SetTrace configures how requests are logged: at TraceVerbose method, URL, status and latency, at TraceDebug
+// also headers and bodies; with curl, an equivalent curl command is printed for each request
+func SetTrace(level int, curl bool) {
+	traceLevel = level
+	printCurl = curl
+	httpClient = nil
+}
+
+// Wraps a transport when tracing is enabled
+func traceTransport(next http.RoundTripper) http.RoundTripper {
+	if traceLevel == TraceOff && !printCurl {
+		return next
+	}
+	return &tracingTransport{next: next}
+}
+
+type tracingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if (traceLevel >= TraceDebug || printCurl) && req.Body != nil && req.Body != http.NoBody && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(io.LimitReader(body, maxTracedBody+1))
+			body.Close()
+		}
+	}
+
+	if printCurl {
+		log.Println(curlCommand(req, reqBody))
+	}
+	if traceLevel >= TraceDebug {
+		log.Printf("> %v %v\n", req.Method, redactURL(req.URL))
+		logHeaders(">", req.Header)
+		logBody(">", req.Header.Get("Content-Type"), reqBody)
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	if traceLevel == TraceOff {
+		return resp, err
+	}
+	if err != nil {
+		log.Printf("%v %v failed after %v: %v\n", req.Method, redactURL(req.URL), elapsed, err)
+		return resp, err
+	}
+	log.Printf("%v %v -> %v (%v)\n", req.Method, redactURL(req.URL), resp.Status, elapsed)
+
+	if traceLevel >= TraceDebug {
+		logHeaders("<", resp.Header)
+		if isTextual(resp.Header.Get("Content-Type")) && resp.ContentLength <= maxTracedBody {
+			// Read the start of the body, then hand it back to the caller unchanged
+			head, _ := io.ReadAll(io.LimitReader(resp.Body, maxTracedBody+1))
+			resp.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+			logBody("<", resp.Header.Get("Content-Type"), head)
+		}
+	}
+
+	return resp, err
+}
+
+func logHeaders(prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			log.Printf("%v %v: %v\n", prefix, name, redactHeader(name, value))
+		}
+	}
+}
+
+func logBody(prefix string, contentType string, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	if len(body) > maxTracedBody {
+		log.Printf("%v (body larger than %v bytes not shown)\n", prefix, maxTracedBody)
+		return
+	}
+	if !isTextual(contentType) {
+		log.Printf("%v (%v bytes of %v)\n", prefix, len(body), contentType)
+		return
+	}
+	log.Printf("%v %v\n", prefix, redactBody(contentType, body))
+}
+
+func isTextual(contentType string) bool {
+	return contentType == "" || strings.Contains(contentType, "json") || strings.HasPrefix(contentType, "text/") ||
+		strings.Contains(contentType, "x-www-form-urlencoded") || strings.Contains(contentType, "xml")
+}
+
+// Reports whether a header, query parameter or field may carry a credential
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	if name == "code" {
+		// Authorization code exchanged for tokens
+		return true
+	}
+	for _, part := range []string{"token", "secret", "password", "credential", "signature", "authorization", "cookie", "verifier", "api-key", "apikey"} {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactHeader(name string, value string) string {
+	if !isSensitive(name) {
+		return value
+	}
+	// Keep the authentication scheme, which helps telling Basic from Bearer
+	if scheme, _, found := strings.Cut(value, " "); found && strings.EqualFold(name, "Authorization") {
+		return scheme + " " + redacted
+	}
+	return redacted
+}
+
+func redactURL(u *url.URL) string {
+	c := *u
+	c.User = nil
+	c.RawQuery = redactQuery(c.RawQuery)
+	return c.String()
+}
+
+// Redacts the values of sensitive parameters in a query string or form body, keeping the rest as is
+func redactQuery(query string) string {
+	if query == "" {
+		return query
+	}
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		key, _, found := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(key); err == nil && found && isSensitive(name) {
+			params[i] = key + "=" + redacted
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+func redactBody(contentType string, body []byte) string {
+	if strings.Contains(contentType, "x-www-form-urlencoded") {
+		return redactQuery(string(body))
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err == nil {
+		if out, err := json.Marshal(redactJSON(doc)); err == nil {
+			return string(out)
+		}
+	}
+	return string(body)
+}
+
+func redactJSON(doc interface{}) interface{} {
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if _, isString := value.(string); isString && isSensitive(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactJSON(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactJSON(value)
+		}
+	}
+	return doc
+}
+
+// Builds a curl command equivalent to the request, with credentials redacted, to paste into bug reports
+func curlCommand(req *http.Request, body []byte) string {
+	var b strings.Builder
+	b.WriteString("curl")
+	if req.Method != http.MethodGet || len(body) > 0 {
+		fmt.Fprintf(&b, " -X %v", req.Method)
+	}
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			fmt.Fprintf(&b, " -H %v", shellQuote(name+": "+redactHeader(name, value)))
+		}
+	}
+
+	if len(body) > maxTracedBody {
+		b.WriteString(" --data-binary @body")
+	} else if len(body) > 0 {
+		fmt.Fprintf(&b, " --data-raw %v", shellQuote(redactBody(req.Header.Get("Content-Type"), body)))
+	}
+
+	fmt.Fprintf(&b, " %v", shellQuote(redactURL(req.URL)))
+	return b.String()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
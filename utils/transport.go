@@ -0,0 +1,177 @@
+// SPDX-FileCopyrightText: © 2025 DSLab - Fondazione Bruno Kessler
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/ini.v1"
+
+	"dhcli/pkg/dhcore"
+)
+
+// Keys configuring how the CLI connects to the core and to the storage of an environment
+const (
+	CaBundleKey           = "ca_bundle"
+	ClientCertKey         = "client_cert"
+	ClientKeyKey          = "client_key"
+	InsecureSkipVerifyKey = "insecure_skip_verify"
+	ProxyUrlKey           = "proxy_url"
+	RequestTimeoutKey     = "request_timeout"
+	RetriesKey            = "retries"
+	RetryBudgetKey        = "retry_budget"
+)
+
+// Connection settings, kept when an environment is registered again
+var TransportKeys = []string{CaBundleKey, ClientCertKey, ClientKeyKey, InsecureSkipVerifyKey, ProxyUrlKey, RequestTimeoutKey,
+	RetriesKey, RetryBudgetKey}
+
+// Shared by all HTTP callers, replaced when an environment is loaded
+var httpClient *http.Client
+
+// Number of retries set with --retries, overriding the environment settings; negative if not set
+var retriesOverride = -1
+
+// SetRetries overrides the number of retries of the environment settings, applied by the next ConfigureHTTPClient
+func SetRetries(retries int) {
+	retriesOverride = retries
+	httpClient = nil
+}
+
+// HTTPClient returns the client used for requests to the core, configured for the loaded environment
+func HTTPClient() *http.Client {
+	if httpClient == nil {
+		// No environment loaded yet: settings come from environment variables only
+		if err := ConfigureHTTPClient(nil, nil); err != nil {
+			log.Printf("WARNING: Ignoring connection settings: %v\n", err)
+			httpClient = &http.Client{Transport: cassetteTransport(http.DefaultTransport, retryPolicy(dhcore.DefaultRetryPolicy.MaxRetries, dhcore.DefaultRetryPolicy.Budget))}
+		}
+	}
+	return httpClient
+}
+
+// StreamingHTTPClient returns a client sharing the transport of HTTPClient, whose requests are not bound
+// by the request timeout, for downloads and uploads that may take long
+func StreamingHTTPClient() *http.Client {
+	return &http.Client{Transport: HTTPClient().Transport}
+}
+
+// ConfigureHTTPClient builds the shared HTTP client from the settings of an environment; settings it does
+// not define are read from the DEFAULT section, then from environment variables. The section may be nil.
+func ConfigureHTTPClient(cfg *ini.File, section *ini.Section) error {
+	setting := func(key string) string {
+		if section != nil && section.HasKey(key) {
+			return section.Key(key).Value()
+		}
+		if cfg != nil {
+			if defaultSection := cfg.Section("DEFAULT"); defaultSection.HasKey(key) {
+				return defaultSection.Key(key).Value()
+			}
+		}
+		return os.Getenv(EnvVarName(key))
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caBundle := setting(CaBundleKey); caBundle != "" {
+		pem, err := os.ReadFile(caBundle)
+		if err != nil {
+			return fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in CA bundle %v", caBundle)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	certFile, keyFile := setting(ClientCertKey), setting(ClientKeyKey)
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return fmt.Errorf("both %v and %v are required for client certificate authentication", ClientCertKey, ClientKeyKey)
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if insecure := setting(InsecureSkipVerifyKey); insecure != "" {
+		skip, err := strconv.ParseBool(insecure)
+		if err != nil {
+			return fmt.Errorf("invalid %v '%v'", InsecureSkipVerifyKey, insecure)
+		}
+		tlsConfig.InsecureSkipVerify = skip
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	// Without an explicit proxy, HTTP_PROXY, HTTPS_PROXY and NO_PROXY apply
+	if proxy := setting(ProxyUrlKey); proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil || proxyURL.Host == "" {
+			return fmt.Errorf("invalid %v '%v'", ProxyUrlKey, proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	timeout := time.Duration(0)
+	if t := setting(RequestTimeoutKey); t != "" {
+		d, err := time.ParseDuration(t)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid %v '%v'", RequestTimeoutKey, t)
+		}
+		timeout = d
+		transport.ResponseHeaderTimeout = d
+	}
+
+	retries := dhcore.DefaultRetryPolicy.MaxRetries
+	if r := setting(RetriesKey); r != "" {
+		n, err := strconv.Atoi(r)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid %v '%v'", RetriesKey, r)
+		}
+		retries = n
+	}
+	if retriesOverride >= 0 {
+		retries = retriesOverride
+	}
+
+	budget := dhcore.DefaultRetryPolicy.Budget
+	if b := setting(RetryBudgetKey); b != "" {
+		d, err := time.ParseDuration(b)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid %v '%v'", RetryBudgetKey, b)
+		}
+		budget = d
+	}
+
+	httpClient = &http.Client{Transport: cassetteTransport(transport, retryPolicy(retries, budget)), Timeout: timeout}
+	return nil
+}
+
+func retryPolicy(retries int, budget time.Duration) dhcore.RetryPolicy {
+	policy := dhcore.DefaultRetryPolicy
+	policy.MaxRetries = retries
+	policy.Budget = budget
+	policy.OnRetry = func(req *http.Request, attempt int, delay time.Duration, reason string) {
+		// Query strings are left out, as they may carry presigned credentials
+		target := req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+		log.Printf("WARNING: %v %v failed (%v), retry %v/%v in %v\n", req.Method, target, reason, attempt, retries, delay.Round(time.Millisecond))
+	}
+	return policy
+}